@@ -1,21 +1,51 @@
 package main
 
 import (
+	"errors"
+	"time"
+
 	"github.com/caarlos0/env/v6"
 )
 
 // Configuration represents application configuration for serve action.
 type Configuration struct {
 	Base      BaseConfig
+	Metrics   MetricsConfig
 	Database  DatabaseConfig
 	Minio     MinioConfig
 	Images    ImagesConfig
 	Proxy     ProxyConfig
 	Detection DetectionConfig
+	Tasks     TasksConfig
+	Ingest    IngestConfig
+}
+
+// IngestConfig lists the sources the parser creates tasks from, as "kind:location" specs.
+type IngestConfig struct {
+	Sources     []string      `env:"INGEST_SOURCES" envSeparator:"," envDefault:"urls:assets/urls/"`
+	FeedTimeout time.Duration `env:"INGEST_FEED_TIMEOUT" envDefault:"30s"`
+}
+
+type TasksConfig struct {
+	LeaseDuration  time.Duration `env:"TASK_LEASE_DURATION" envDefault:"2m"`
+	RetryBaseDelay time.Duration `env:"TASK_RETRY_BASE_DELAY" envDefault:"1m"`
+	RetryMaxDelay  time.Duration `env:"TASK_RETRY_MAX_DELAY" envDefault:"6h"`
+	// DuplicateMode is one of off, reject or link.
+	DuplicateMode     string `env:"TASK_DUPLICATE_MODE" envDefault:"reject"`
+	DuplicateDistance int    `env:"TASK_DUPLICATE_DISTANCE" envDefault:"6"`
 }
 
 type BaseConfig struct {
 	WithFill bool `env:"WITH_FILL" envDefault:"true"`
+	// Daemon keeps the parser running and polling for new tasks instead of exiting once the queue is drained.
+	Daemon       bool          `env:"PARSER_DAEMON" envDefault:"false"`
+	ListenNotify bool          `env:"PARSER_LISTEN_NOTIFY" envDefault:"true"`
+	Workers      int           `env:"PARSER_WORKERS" envDefault:"50"`
+	PollInterval time.Duration `env:"PARSER_POLL_INTERVAL" envDefault:"10s"`
+}
+
+type MetricsConfig struct {
+	Port int `env:"METRICS_PORT" envDefault:"9090"`
 }
 
 type ProxyConfig struct {
@@ -24,7 +54,13 @@ type ProxyConfig struct {
 }
 
 type ImagesConfig struct {
-	OptimizerEndpoint string `env:"IMAGES_OPTIMIZER_ENDPOINT" envDefault:"http://image-optimizer.image-optimizer:3000"`
+	// Processor resizes the previews: native or optimizer.
+	Processor          string `env:"IMAGES_PROCESSOR" envDefault:"native"`
+	Quality            int    `env:"IMAGES_QUALITY" envDefault:"85"`
+	OptimizerEndpoint  string `env:"IMAGES_OPTIMIZER_ENDPOINT" envDefault:"http://image-optimizer.image-optimizer:3000"`
+	OptimizerSourceURL string `env:"IMAGES_OPTIMIZER_SOURCE_URL" envDefault:"http://minio.minio:9000/tits"`
+	// Renditions are the "name:width" sizes stored for every card, "card" is used for the card URL.
+	Renditions []string `env:"IMAGES_RENDITIONS" envSeparator:"," envDefault:"thumb:160,card:350,large:1024,original"`
 }
 
 type MinioConfig struct {
@@ -40,7 +76,27 @@ type DatabaseConfig struct {
 }
 
 type DetectionConfig struct {
-	BaseUrl string `env:"DETECTION_BASE_URL" envDefault:"http://nsfw-detector-nude-detector.nude-detector:80"`
+	BaseUrl          string        `env:"DETECTION_BASE_URL" envDefault:"http://nsfw-detector-nude-detector.nude-detector:80"`
+	Timeout          time.Duration `env:"DETECTION_TIMEOUT" envDefault:"30s"`
+	MaxRetries       int           `env:"DETECTION_MAX_RETRIES" envDefault:"2"`
+	RetryBaseDelay   time.Duration `env:"DETECTION_RETRY_BASE_DELAY" envDefault:"500ms"`
+	MaxConcurrent    int           `env:"DETECTION_MAX_CONCURRENT" envDefault:"8"`
+	BreakerThreshold int           `env:"DETECTION_BREAKER_THRESHOLD" envDefault:"5"`
+	BreakerCooldown  time.Duration `env:"DETECTION_BREAKER_COOLDOWN" envDefault:"30s"`
+	// PolicyFile is a JSON or YAML detection policy, the built-in policy is used when it's empty.
+	PolicyFile string `env:"DETECTION_POLICY_FILE" envDefault:""`
+
+	// Backend selects the detector: http, http_secondary, ensemble or fake.
+	Backend          string   `env:"DETECTION_BACKEND" envDefault:"http"`
+	SecondaryBaseUrl string   `env:"DETECTION_SECONDARY_BASE_URL" envDefault:""`
+	EnsembleBackends []string `env:"DETECTION_ENSEMBLE_BACKENDS" envSeparator:"," envDefault:"http,http_secondary"`
+	EnsembleMerge    string   `env:"DETECTION_ENSEMBLE_MERGE" envDefault:"max"`
+	FakeFixtures     string   `env:"DETECTION_FAKE_FIXTURES" envDefault:""`
+
+	// Results are cached by the image hash, in memory for up to CacheSize images and in the database.
+	CacheEnabled bool          `env:"DETECTION_CACHE_ENABLED" envDefault:"true"`
+	CacheSize    int           `env:"DETECTION_CACHE_SIZE" envDefault:"10000"`
+	CacheTTL     time.Duration `env:"DETECTION_CACHE_TTL" envDefault:"720h"`
 }
 
 // LoadConfiguration returns a new application configuration parsed from environment variables.
@@ -49,6 +105,23 @@ func LoadConfiguration() (*Configuration, error) {
 	if err := env.Parse(&config); err != nil {
 		return nil, err
 	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
 
 	return &config, nil
 }
+
+func (c *Configuration) validate() error {
+	if c.Base.Workers < 1 {
+		return errors.New("PARSER_WORKERS must be at least 1")
+	}
+	if c.Base.PollInterval <= 0 {
+		return errors.New("PARSER_POLL_INTERVAL must be positive")
+	}
+	// The lease is extended every third of its duration.
+	if c.Tasks.LeaseDuration < time.Second {
+		return errors.New("TASK_LEASE_DURATION must be at least 1s")
+	}
+	return nil
+}
@@ -1,15 +1,26 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"net/http"
+	"sort"
+	"strings"
 
 	"github.com/boobsrate/core/internal/applications/parser"
 	"github.com/boobsrate/core/internal/clients/detection"
+	"github.com/boobsrate/core/internal/domain"
 	"github.com/boobsrate/core/internal/repository/postgres"
 	"github.com/boobsrate/core/internal/services/detector"
+	"github.com/boobsrate/core/internal/services/images"
 	"github.com/boobsrate/core/internal/services/tits"
 	storage "github.com/boobsrate/core/internal/storage/minio"
+	"github.com/boobsrate/core/pkg/observer"
+	"github.com/boobsrate/core/pkg/server"
+	"github.com/boobsrate/core/pkg/tracing"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
 	"go.uber.org/zap"
@@ -39,13 +50,249 @@ func main() {
 		logger.Fatal("creating minio client: ", zap.Error(err))
 	}
 	titsStorage := storage.NewMinioStorage(minioClient, cfg.Minio.Bucket, "" /* publicURL */)
-	titsService := tits.NewService(titsRepo, titsStorage, logger, nil, cfg.Images.OptimizerEndpoint)
+	imageProcessor, err := images.NewProcessor(images.Config{
+		Kind:               cfg.Images.Processor,
+		Quality:            cfg.Images.Quality,
+		OptimizerEndpoint:  cfg.Images.OptimizerEndpoint,
+		OptimizerSourceURL: cfg.Images.OptimizerSourceURL,
+	})
+	if err != nil {
+		logger.Fatal("creating image processor: ", zap.Error(err))
+	}
+	renditions, err := domain.ParseRenditionSpecs(cfg.Images.Renditions)
+	if err != nil {
+		logger.Fatal("parse image renditions: ", zap.Error(err))
+	}
+	titsService := tits.NewService(titsRepo, titsStorage, logger, nil, imageProcessor, renditions)
 
 	tasksRepo := postgres.NewTasksRepository(pgDB)
 
-	detectionCli := detection.NewClient(cfg.Detection.BaseUrl)
-	detectionSvc := detector.NewService(logger.Named("detector"), detectionCli)
+	detectionBackend, err := newDetectorRegistry(cfg.Detection).Get(cfg.Detection.Backend)
+	if err != nil {
+		logger.Fatal("creating detector backend: ", zap.Error(err))
+	}
+	if cfg.Detection.CacheEnabled {
+		cacheRepo := postgres.NewDetectionCacheRepository(pgDB)
+		if deleted, err := cacheRepo.DeleteExpiredDetections(context.Background()); err != nil {
+			logger.Error("delete expired detections", zap.Error(err))
+		} else {
+			logger.Info("Expired detections deleted", zap.Int64("count", deleted))
+		}
+		detectionBackend = detector.NewCached(detectionBackend, detectorIdentity(cfg.Detection), cacheRepo, cfg.Detection.CacheSize, cfg.Detection.CacheTTL, logger)
+	}
+	detectionSvc := detector.NewService(logger.Named("detector"), detectionBackend)
+
+	detectionPolicy, err := parser.LoadDetectionPolicy(cfg.Detection.PolicyFile)
+	if err != nil {
+		logger.Fatal("loading detection policy: ", zap.Error(err))
+	}
+
+	duplicateMode := parser.DuplicateMode(cfg.Tasks.DuplicateMode)
+	if !duplicateMode.Valid() {
+		logger.Fatal("invalid duplicate mode", zap.String("mode", cfg.Tasks.DuplicateMode))
+	}
+
+	feedClient := &http.Client{Timeout: cfg.Ingest.FeedTimeout}
+	sources := make([]parser.IngestionSource, 0, len(cfg.Ingest.Sources))
+	for _, spec := range cfg.Ingest.Sources {
+		source, err := parser.NewSource(spec, feedClient)
+		if err != nil {
+			logger.Fatal("creating ingestion source: ", zap.Error(err))
+		}
+		sources = append(sources, source)
+	}
+
+	initiatorApp := parser.NewService(logger, titsService, tasksRepo, detectionSvc, cfg.Proxy.ProxyEndpointAll, parser.Config{
+		Workers:        cfg.Base.Workers,
+		PollInterval:   cfg.Base.PollInterval,
+		LeaseDuration:  cfg.Tasks.LeaseDuration,
+		RetryBaseDelay: cfg.Tasks.RetryBaseDelay,
+		RetryMaxDelay:  cfg.Tasks.RetryMaxDelay,
+
+		DetectionPolicy: detectionPolicy,
+
+		DuplicateMode:     duplicateMode,
+		DuplicateDistance: cfg.Tasks.DuplicateDistance,
+	}, sources)
+
+	flag.Parse()
+	switch command := flag.Arg(0); command {
+	case "":
+	case "backfill-detections":
+		if err := initiatorApp.BackfillDetections(context.Background()); err != nil {
+			logger.Fatal("backfill detections", zap.Error(err))
+		}
+		return
+	case "backfill-renditions":
+		if err := initiatorApp.BackfillRenditions(context.Background()); err != nil {
+			logger.Fatal("backfill renditions", zap.Error(err))
+		}
+		return
+	case "policy":
+		if flag.Arg(1) != "test" || flag.Arg(2) == "" {
+			logger.Fatal("usage: parser policy test <policy.json|policy.yaml>")
+		}
+		if err := testPolicy(initiatorApp, flag.Arg(2)); err != nil {
+			logger.Fatal("test detection policy", zap.Error(err))
+		}
+		return
+	case "dedupe":
+		if err := dedupe(initiatorApp, flag.Args()[1:]); err != nil {
+			logger.Fatal("dedupe cards", zap.Error(err))
+		}
+		return
+	default:
+		logger.Fatal("unknown command", zap.String("command", command))
+	}
+
+	if !cfg.Base.Daemon {
+		initiatorApp.Run(cfg.Base.WithFill)
+		return
+	}
+
+	if err := runDaemon(cfg, logger, initiatorApp, tasksRepo); err != nil {
+		logger.Fatal("run parser daemon", zap.Error(err))
+	}
+}
+
+// dedupe prints the clusters of near duplicate cards and merges them with --merge.
+func dedupe(app *parser.Service, args []string) error {
+	flags := flag.NewFlagSet("dedupe", flag.ContinueOnError)
+	merge := flags.Bool("merge", false, "merge the duplicates into the original card")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	clusters, err := app.Dedupe(context.Background(), *merge)
+	for _, cluster := range clusters {
+		ids := make([]string, 0, len(cluster.Duplicates))
+		for _, duplicate := range cluster.Duplicates {
+			ids = append(ids, duplicate.ID)
+		}
+		fmt.Printf("%s: %s\n", cluster.Original.ID, strings.Join(ids, " "))
+	}
+	fmt.Printf("duplicate clusters: %d\n", len(clusters))
+	return err
+}
+
+// newDetectorRegistry registers the detector backends that can be selected with DETECTION_BACKEND.
+func newDetectorRegistry(cfg DetectionConfig) *detector.Registry {
+	clientConfig := detection.Config{
+		Timeout:          cfg.Timeout,
+		MaxRetries:       cfg.MaxRetries,
+		RetryBaseDelay:   cfg.RetryBaseDelay,
+		MaxConcurrent:    cfg.MaxConcurrent,
+		BreakerThreshold: cfg.BreakerThreshold,
+		BreakerCooldown:  cfg.BreakerCooldown,
+	}
+
+	registry := detector.NewRegistry()
+	registry.Register("http", func(*detector.Registry) (detector.Backend, error) {
+		return detection.NewClient(cfg.BaseUrl, clientConfig), nil
+	})
+	registry.Register("http_secondary", func(*detector.Registry) (detector.Backend, error) {
+		if cfg.SecondaryBaseUrl == "" {
+			return nil, errors.New("DETECTION_SECONDARY_BASE_URL is not set")
+		}
+		return detection.NewClient(cfg.SecondaryBaseUrl, clientConfig), nil
+	})
+	registry.Register("ensemble", func(registry *detector.Registry) (detector.Backend, error) {
+		backends := make([]detector.Backend, 0, len(cfg.EnsembleBackends))
+		for _, name := range cfg.EnsembleBackends {
+			backend, err := registry.Get(name)
+			if err != nil {
+				return nil, err
+			}
+			backends = append(backends, backend)
+		}
+		return detector.NewEnsemble(detector.MergeMode(cfg.EnsembleMerge), backends...)
+	})
+	registry.Register("fake", func(*detector.Registry) (detector.Backend, error) {
+		if cfg.FakeFixtures == "" {
+			return nil, errors.New("DETECTION_FAKE_FIXTURES is not set")
+		}
+		return detector.NewFakeFromFile(cfg.FakeFixtures)
+	})
+	return registry
+}
+
+// detectorIdentity names the configured backend for the detection cache, including everything
+// that changes its results: the members and merge mode of an ensemble, the fixtures of the fake.
+func detectorIdentity(cfg DetectionConfig) string {
+	switch cfg.Backend {
+	case "ensemble":
+		return fmt.Sprintf("ensemble(%s:%s)", cfg.EnsembleMerge, strings.Join(cfg.EnsembleBackends, ","))
+	case "fake":
+		return "fake(" + cfg.FakeFixtures + ")"
+	default:
+		return cfg.Backend
+	}
+}
+
+// testPolicy prints how the outcome of finished tasks would change under the candidate policy.
+func testPolicy(app *parser.Service, path string) error {
+	policy, err := parser.LoadDetectionPolicy(path)
+	if err != nil {
+		return err
+	}
+
+	report, err := app.TestDetectionPolicy(context.Background(), policy)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("tasks evaluated: %d\n", report.Tasks)
+	fmt.Printf("accepted -> rejected: %d\n", report.NowRejected)
+	fmt.Printf("rejected -> accepted: %d\n", report.NowAccepted)
+
+	rules := make([]string, 0, len(report.Rules))
+	for rule := range report.Rules {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+	for _, rule := range rules {
+		fmt.Printf("rejected by %s: %d\n", rule, report.Rules[rule])
+	}
+	return nil
+}
+
+func runDaemon(cfg *Configuration, logger *zap.Logger, app *parser.Service, tasksRepo *postgres.TasksRepository) error {
+	metricsServer := server.NewGracefulServer(&http.Server{
+		Addr:    fmt.Sprintf(":%d", cfg.Metrics.Port),
+		Handler: tracing.NewGracefulMetricsServer(),
+	}, logger.Named("metrics_server"))
+
+	obs := observer.NewObserver()
+
+	obs.AddOpener(observer.OpenerFunc(func() error {
+		return metricsServer.Serve()
+	}))
+
+	obs.AddContextCloser(observer.ContextCloserFunc(func(ctx context.Context) error {
+		return metricsServer.Shutdown(ctx)
+	}))
+
+	obs.AddUpper(func(ctx context.Context) {
+		var tasksCreated <-chan struct{}
+		if cfg.Base.ListenNotify {
+			var err error
+			tasksCreated, err = tasksRepo.ListenTasks(ctx)
+			if err != nil {
+				logger.Error("listen for new tasks, falling back to polling", zap.Error(err))
+			}
+		}
+		if cfg.Base.WithFill {
+			app.Fill(ctx)
+		}
+		app.Serve(ctx, tasksCreated)
+	})
+
+	obs.AddUpper(func(ctx context.Context) {
+		select {
+		case <-ctx.Done():
+		case <-metricsServer.Dead():
+		}
+	})
 
-	initiatorApp := parser.NewService(logger, titsService, tasksRepo, detectionSvc, cfg.Proxy.ProxyEndpointAll)
-	initiatorApp.Run(cfg.Base.WithFill)
+	return obs.Run()
 }
@@ -2,27 +2,34 @@ package abyss
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"github.com/boobsrate/core/internal/config"
+	"github.com/boobsrate/core/internal/domain"
 	"go.uber.org/zap"
 )
 
 const (
-	defaultCheckTimeout   = time.Second * 10
-	defaultAbyssThreshold = 1
+	restorePolicy    = "abyss_votes"
+	reconsiderPolicy = "report_rejected"
 )
 
 type Keeper struct {
-	log     *zap.Logger
-	service Service
-	dead    chan struct{}
+	log      *zap.Logger
+	service  Service
+	cfg      config.AbyssConfig
+	policies []Policy
+	dead     chan struct{}
 }
 
-func NewKeeper(log *zap.Logger, service Service) *Keeper {
+func NewKeeper(log *zap.Logger, service Service, cfg config.AbyssConfig, policies []Policy) *Keeper {
 	return &Keeper{
-		log:     log.Named("abyss_keeper"),
-		service: service,
-		dead:    make(chan struct{}),
+		log:      log.Named("abyss_keeper"),
+		service:  service,
+		cfg:      cfg,
+		policies: policies,
+		dead:     make(chan struct{}),
 	}
 }
 
@@ -31,17 +38,148 @@ func (k *Keeper) Dead() chan struct{} {
 }
 
 func (k *Keeper) iterate(ctx context.Context) {
-	tits, err := k.service.GetTitsWithReportsThreshold(ctx, defaultAbyssThreshold)
+	now := time.Now().UTC()
+	var since time.Time
+	if k.cfg.Window > 0 {
+		since = now.Add(-k.cfg.Window)
+	}
+
+	candidates, err := k.service.GetAbyssCandidates(ctx, since)
 	if err != nil {
-		k.log.Error("get tit with reports threshold", zap.Error(err))
+		k.log.Error("get abyss candidates", zap.Error(err))
 		return
 	}
-	for _, tit := range tits {
-		k.log.Info("move tit to abyss", zap.String("tit_id", tit.ID))
-		if err := k.service.MoveToAbyss(ctx, tit.ID); err != nil {
-			k.log.Error("move tit to abyss", zap.String("tit_id", tit.ID), zap.Error(err))
+	for _, candidate := range candidates {
+		policy, decision, ok := k.evaluate(candidate, now)
+		if !ok {
+			continue
+		}
+
+		fields := []zap.Field{
+			zap.String("tit_id", candidate.Tits.ID),
+			zap.String("policy", policy),
+			zap.Any("inputs", decision.Inputs),
+		}
+		if k.cfg.DryRun {
+			k.log.Info("dry run, would move tit to abyss", fields...)
+			continue
+		}
+
+		k.log.Info("move tit to abyss", fields...)
+		err := k.service.ApplyAbyssEvent(ctx, domain.AbyssEvent{
+			TitsID: candidate.Tits.ID,
+			Action: domain.AbyssActionMove,
+			Policy: policy,
+			Inputs: decision.Inputs,
+		})
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			k.log.Error("move tit to abyss", append(fields, zap.Error(err))...)
+		}
+	}
+}
+
+// restore brings back cards that collected enough votes in the abyss.
+func (k *Keeper) restore(ctx context.Context) {
+	if k.cfg.RestoreVotes <= 0 {
+		return
+	}
+
+	candidates, err := k.service.GetAbyssRestoreCandidates(ctx, k.cfg.RestoreVotes)
+	if err != nil {
+		k.log.Error("get abyss restore candidates", zap.Error(err))
+		return
+	}
+	for _, candidate := range candidates {
+		inputs := map[string]interface{}{
+			"votes":     candidate.Votes,
+			"threshold": k.cfg.RestoreVotes,
+		}
+		fields := []zap.Field{
+			zap.String("tit_id", candidate.Tits.ID),
+			zap.Any("inputs", inputs),
+		}
+		if k.cfg.DryRun {
+			k.log.Info("dry run, would restore tit from abyss", fields...)
+			continue
+		}
+
+		k.log.Info("restore tit from abyss", fields...)
+		err := k.service.ApplyAbyssEvent(ctx, domain.AbyssEvent{
+			TitsID: candidate.Tits.ID,
+			Action: domain.AbyssActionRestore,
+			Policy: restorePolicy,
+			Inputs: inputs,
+		})
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			k.log.Error("restore tit from abyss", append(fields, zap.Error(err))...)
+		}
+	}
+}
+
+// Reconsider re-evaluates a card in the abyss after one of its reports was rejected, and restores it
+// when no policy would move it any more. Only cards the keeper moved are reconsidered, all of their
+// active reports are counted regardless of the window.
+func (k *Keeper) Reconsider(ctx context.Context, titsID, reportID string) error {
+	history, err := k.service.GetAbyssHistory(ctx, titsID)
+	if err != nil {
+		return err
+	}
+	// The history is ordered from the newest event, moves by moderators have an actor.
+	if len(history) == 0 {
+		return nil
+	}
+	last := history[0]
+	if last.Action != domain.AbyssActionMove || last.ActorID != nil {
+		return nil
+	}
+
+	candidate, err := k.service.GetAbyssCandidate(ctx, titsID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, _, move := k.evaluate(candidate, time.Now().UTC()); move {
+		return nil
+	}
+
+	inputs := map[string]interface{}{
+		"rejected_report_id": reportID,
+		"reports":            len(candidate.Reports),
+		"moved_by":           last.Policy,
+	}
+	fields := []zap.Field{
+		zap.String("tit_id", titsID),
+		zap.Any("inputs", inputs),
+	}
+	if k.cfg.DryRun {
+		k.log.Info("dry run, would restore tit after rejected report", fields...)
+		return nil
+	}
+
+	k.log.Info("restore tit after rejected report", fields...)
+	err = k.service.ApplyAbyssEvent(ctx, domain.AbyssEvent{
+		TitsID: titsID,
+		Action: domain.AbyssActionRestore,
+		Policy: reconsiderPolicy,
+		Inputs: inputs,
+	})
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// evaluate returns the first policy that decides to move the card.
+func (k *Keeper) evaluate(candidate domain.AbyssCandidate, now time.Time) (string, Decision, bool) {
+	for _, policy := range k.policies {
+		decision := policy.Evaluate(candidate, now)
+		if decision.Move {
+			return policy.Name(), decision, true
 		}
 	}
+	return "", Decision{}, false
 }
 
 func (k *Keeper) Run(ctx context.Context) {
@@ -49,7 +187,7 @@ func (k *Keeper) Run(ctx context.Context) {
 	defer close(k.dead)
 	defer k.log.Info("abyss keeper stopped")
 
-	ticker := time.NewTicker(defaultCheckTimeout)
+	ticker := time.NewTicker(k.cfg.CheckInterval)
 
 	for {
 		select {
@@ -62,7 +200,10 @@ func (k *Keeper) Run(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			go k.iterate(ctx)
+			go func() {
+				k.iterate(ctx)
+				k.restore(ctx)
+			}()
 		}
 	}
 }
@@ -2,11 +2,15 @@ package abyss
 
 import (
 	"context"
+	"time"
 
 	"github.com/boobsrate/core/internal/domain"
 )
 
 type Service interface {
-	MoveToAbyss(ctx context.Context, titsID string) error
-	GetTitsWithReportsThreshold(ctx context.Context, reportsThreshold int) ([]domain.Tits, error)
+	GetAbyssCandidates(ctx context.Context, since time.Time) ([]domain.AbyssCandidate, error)
+	GetAbyssCandidate(ctx context.Context, titsID string) (domain.AbyssCandidate, error)
+	GetAbyssHistory(ctx context.Context, titsID string) ([]domain.AbyssEvent, error)
+	GetAbyssRestoreCandidates(ctx context.Context, minVotes int) ([]domain.AbyssRestoreCandidate, error)
+	ApplyAbyssEvent(ctx context.Context, event domain.AbyssEvent) error
 }
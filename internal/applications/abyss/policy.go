@@ -0,0 +1,125 @@
+package abyss
+
+import (
+	"time"
+
+	"github.com/boobsrate/core/internal/config"
+	"github.com/boobsrate/core/internal/domain"
+)
+
+// Decision is the verdict of a policy on a single card.
+// Inputs are stored in the abyss history so that false positives can be audited.
+type Decision struct {
+	Move   bool
+	Inputs map[string]interface{}
+}
+
+type Policy interface {
+	Name() string
+	Evaluate(candidate domain.AbyssCandidate, now time.Time) Decision
+}
+
+// NewPolicies builds the policies enabled in the configuration.
+func NewPolicies(cfg config.AbyssConfig) []Policy {
+	weight := reportWeight{
+		newAccountAge:    cfg.NewAccountAge,
+		newAccountWeight: cfg.NewAccountWeight,
+	}
+
+	policies := []Policy{
+		thresholdPolicy{
+			weight:    weight,
+			threshold: cfg.Threshold,
+		},
+	}
+	if cfg.Ratio > 0 {
+		policies = append(policies, ratioPolicy{
+			weight:         weight,
+			ratio:          cfg.Ratio,
+			minImpressions: cfg.MinImpressions,
+			minReports:     cfg.MinReports,
+		})
+	}
+	return policies
+}
+
+type reportWeight struct {
+	newAccountAge    time.Duration
+	newAccountWeight float64
+}
+
+// weigh scales a report by the share of its author's reports that moderators accepted,
+// smoothed so that a reporter without decided reports weighs 1. Reports from new or
+// unknown accounts are discounted further.
+func (w reportWeight) weigh(report domain.AbyssReport, now time.Time) float64 {
+	decided := float64(report.ReporterAccepted + report.ReporterRejected)
+	weight := 2 * (float64(report.ReporterAccepted) + 1) / (decided + 2)
+	if report.ReporterID == "" || now.Sub(report.ReporterCreatedAt) < w.newAccountAge {
+		weight *= w.newAccountWeight
+	}
+	return weight
+}
+
+func (w reportWeight) total(reports []domain.AbyssReport, now time.Time) float64 {
+	var total float64
+	for _, report := range reports {
+		total += w.weigh(report, now)
+	}
+	return total
+}
+
+// thresholdPolicy moves a card once its weighted reports reach the threshold.
+type thresholdPolicy struct {
+	weight    reportWeight
+	threshold float64
+}
+
+func (p thresholdPolicy) Name() string {
+	return "weighted_threshold"
+}
+
+func (p thresholdPolicy) Evaluate(candidate domain.AbyssCandidate, now time.Time) Decision {
+	weighted := p.weight.total(candidate.Reports, now)
+	return Decision{
+		Move: weighted >= p.threshold,
+		Inputs: map[string]interface{}{
+			"reports":          len(candidate.Reports),
+			"weighted_reports": weighted,
+			"threshold":        p.threshold,
+		},
+	}
+}
+
+// ratioPolicy moves a card once its weighted reports per impression reach the ratio.
+// Cards with too few impressions or reports are left alone.
+type ratioPolicy struct {
+	weight         reportWeight
+	ratio          float64
+	minImpressions int64
+	minReports     float64
+}
+
+func (p ratioPolicy) Name() string {
+	return "reports_ratio"
+}
+
+func (p ratioPolicy) Evaluate(candidate domain.AbyssCandidate, now time.Time) Decision {
+	weighted := p.weight.total(candidate.Reports, now)
+	impressions := candidate.Tits.Impressions
+
+	var ratio float64
+	if impressions > 0 {
+		ratio = weighted / float64(impressions)
+	}
+
+	return Decision{
+		Move: impressions >= p.minImpressions && weighted >= p.minReports && ratio >= p.ratio,
+		Inputs: map[string]interface{}{
+			"reports":          len(candidate.Reports),
+			"weighted_reports": weighted,
+			"impressions":      impressions,
+			"ratio":            ratio,
+			"threshold_ratio":  p.ratio,
+		},
+	}
+}
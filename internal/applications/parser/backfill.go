@@ -0,0 +1,76 @@
+package parser
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+const backfillBatchSize = 500
+
+// BackfillDetections copies the detector results of finished tasks onto the cards created from them.
+// Cards share the ID of their task, so cards created before detections were stored get them and the derived tags.
+func (s *Service) BackfillDetections(ctx context.Context) error {
+	var afterID string
+	var updated, failed int
+	for {
+		tasks, err := s.taskService.GetDetectionBackfill(ctx, afterID, backfillBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, task := range tasks {
+			err := s.titsService.SetDetections(ctx, task.ID, task.DetectionResult.Detections)
+			if err != nil {
+				s.log.Error("backfill detections", zap.String("task_id", task.ID), zap.Error(err))
+				failed++
+				continue
+			}
+			updated++
+		}
+
+		if len(tasks) < backfillBatchSize {
+			break
+		}
+		afterID = tasks[len(tasks)-1].ID
+	}
+
+	s.log.Info("Detections backfilled", zap.Int("updated", updated), zap.Int("failed", failed))
+	return nil
+}
+
+// BackfillRenditions renders the configured renditions missing from existing cards out of their original images.
+func (s *Service) BackfillRenditions(ctx context.Context) error {
+	var afterID string
+	var cards, created, failed int
+	for {
+		tits, err := s.titsService.GetRenditionBackfill(ctx, afterID, backfillBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, card := range tits {
+			count, err := s.titsService.CreateMissingRenditions(ctx, card)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				s.log.Error("backfill renditions", zap.String("tits_id", card.ID), zap.Error(err))
+				failed++
+				continue
+			}
+			if count > 0 {
+				cards++
+				created += count
+			}
+		}
+
+		if len(tits) < backfillBatchSize {
+			break
+		}
+		afterID = tits[len(tits)-1].ID
+	}
+
+	s.log.Info("Renditions backfilled", zap.Int("cards", cards), zap.Int("renditions", created), zap.Int("failed", failed))
+	return nil
+}
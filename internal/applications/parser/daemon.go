@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/boobsrate/core/internal/domain"
+	"go.uber.org/zap"
+)
+
+// Serve runs the worker pool until ctx is done. Idle workers poll for claimable tasks
+// every PollInterval and wake up early when tasksCreated signals, which may be nil.
+// Tasks that are in progress when ctx is done are finished before Serve returns.
+func (s *Service) Serve(ctx context.Context, tasksCreated <-chan struct{}) {
+	s.log.Info("Tits downloader daemon started", zap.Int("workers", s.cfg.Workers))
+	defer s.log.Info("Tits downloader daemon stopped")
+
+	wake := make(chan struct{}, s.cfg.Workers)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-tasksCreated:
+				if !ok {
+					return
+				}
+				for i := 0; i < s.cfg.Workers; i++ {
+					select {
+					case wake <- struct{}{}:
+					default:
+					}
+				}
+			}
+		}
+	}()
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < s.cfg.Workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			s.serveWorker(ctx, worker, wake)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func (s *Service) serveWorker(ctx context.Context, worker int, wake <-chan struct{}) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Don't take tasks off the queue while the detector is down.
+		if err := s.detectionService.WaitAvailable(ctx); err != nil {
+			return
+		}
+
+		task, err := s.taskService.ClaimTask(ctx, s.workerID(worker), s.cfg.LeaseDuration)
+		if err != nil {
+			if !errors.Is(err, domain.ErrNoTasks) && ctx.Err() == nil {
+				s.log.Error("claim task", zap.Int("worker", worker), zap.Error(err))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-wake:
+			case <-ticker.C:
+			}
+			continue
+		}
+
+		busyWorkers.Inc()
+		// The task is processed outside of ctx so that shutdown lets it finish.
+		s.handle(context.Background(), task, zap.Int("worker", worker))
+		busyWorkers.Dec()
+	}
+}
@@ -0,0 +1,166 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/boobsrate/core/internal/domain"
+	"github.com/boobsrate/core/pkg/imagehash"
+	"go.uber.org/zap"
+)
+
+// DuplicateMode tells what happens to an image that is a near duplicate of an existing card.
+type DuplicateMode string
+
+const (
+	// DuplicateModeOff doesn't hash images at all.
+	DuplicateModeOff DuplicateMode = "off"
+	// DuplicateModeReject doesn't create a card for the image.
+	DuplicateModeReject DuplicateMode = "reject"
+	// DuplicateModeLink creates the card marked as a duplicate, which keeps it out of duels and the top.
+	DuplicateModeLink DuplicateMode = "link"
+)
+
+func (m DuplicateMode) Valid() bool {
+	switch m {
+	case DuplicateModeOff, DuplicateModeReject, DuplicateModeLink:
+		return true
+	}
+	return false
+}
+
+// findDuplicate hashes the image and looks for an original card with a similar hash.
+// Images that can't be decoded are let through without a hash.
+func (s *Service) findDuplicate(ctx context.Context, log *zap.Logger, image []byte) (*uint64, *string, error) {
+	if s.cfg.DuplicateMode == DuplicateModeOff {
+		return nil, nil, nil
+	}
+
+	dhash, err := imagehash.DHashBytes(image)
+	if err != nil {
+		log.Warn("hash image", zap.Error(err))
+		return nil, nil, nil
+	}
+
+	original, err := s.titsService.FindDuplicate(ctx, dhash, s.cfg.DuplicateDistance)
+	if errors.Is(err, domain.ErrNotFound) {
+		return &dhash, nil, nil
+	}
+	if err != nil {
+		return nil, nil, classify(errorClassTransient, err)
+	}
+
+	log.Info("Image is a duplicate", zap.String("original_id", original.ID))
+	return &dhash, &original.ID, nil
+}
+
+// DuplicateCluster is a group of near duplicate cards. Original is the card the others get merged into.
+type DuplicateCluster struct {
+	Original   domain.Tits
+	Duplicates []domain.Tits
+}
+
+// Dedupe hashes the images of existing cards that have no hash yet and groups the cards into clusters
+// of near duplicates. The card with most impressions, the oldest on a tie, stays the original.
+// Cards whose image can't be loaded, hashed or stored are left out and counted in the summary.
+// With merge the other cards are marked as its duplicates and their votes are moved to it.
+func (s *Service) Dedupe(ctx context.Context, merge bool) ([]DuplicateCluster, error) {
+	cards, err := s.titsService.GetOriginalTits(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hashed := make([]domain.Tits, 0, len(cards))
+	var failed, unhashable int
+	for _, card := range cards {
+		if card.DHash == nil {
+			image, err := s.titsService.GetImage(ctx, card.ID)
+			if err != nil {
+				s.log.Error("get image to hash", zap.String("tits_id", card.ID), zap.Error(err))
+				failed++
+				continue
+			}
+			dhash, err := imagehash.DHashBytes(image)
+			if err != nil {
+				s.log.Warn("hash image", zap.String("tits_id", card.ID), zap.Error(err))
+				unhashable++
+				continue
+			}
+			if err := s.titsService.SetDHash(ctx, card.ID, dhash); err != nil {
+				s.log.Error("store image hash", zap.String("tits_id", card.ID), zap.Error(err))
+				failed++
+				continue
+			}
+			card.DHash = &dhash
+		}
+		hashed = append(hashed, card)
+	}
+	s.log.Info("Cards hashed",
+		zap.Int("hashed", len(hashed)),
+		zap.Int("failed", failed),
+		zap.Int("unhashable", unhashable),
+		zap.Int("total", len(cards)),
+	)
+
+	clusters := clusterDuplicates(hashed, s.cfg.DuplicateDistance)
+	if !merge {
+		return clusters, nil
+	}
+
+	for _, cluster := range clusters {
+		for _, duplicate := range cluster.Duplicates {
+			if err := s.titsService.MergeDuplicate(ctx, cluster.Original.ID, duplicate.ID); err != nil {
+				return clusters, err
+			}
+		}
+	}
+	return clusters, nil
+}
+
+// clusterDuplicates groups cards whose hashes are within maxDistance, transitively.
+func clusterDuplicates(cards []domain.Tits, maxDistance int) []DuplicateCluster {
+	parent := make([]int, len(cards))
+	for idx := range parent {
+		parent[idx] = idx
+	}
+	var find func(idx int) int
+	find = func(idx int) int {
+		if parent[idx] != idx {
+			parent[idx] = find(parent[idx])
+		}
+		return parent[idx]
+	}
+
+	for i := range cards {
+		for j := i + 1; j < len(cards); j++ {
+			if imagehash.Distance(*cards[i].DHash, *cards[j].DHash) <= maxDistance {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	groups := make(map[int][]domain.Tits)
+	for idx, card := range cards {
+		root := find(idx)
+		groups[root] = append(groups[root], card)
+	}
+
+	clusters := make([]DuplicateCluster, 0)
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool {
+			if group[i].Impressions != group[j].Impressions {
+				return group[i].Impressions > group[j].Impressions
+			}
+			return group[i].CreatedAt.Before(group[j].CreatedAt)
+		})
+		clusters = append(clusters, DuplicateCluster{Original: group[0], Duplicates: group[1:]})
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].Original.ID < clusters[j].Original.ID
+	})
+	return clusters
+}
@@ -0,0 +1,256 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/boobsrate/core/internal/domain"
+	"gopkg.in/yaml.v3"
+)
+
+// DetectionPolicy decides whether an image is accepted based on the detector results.
+// An image is accepted when every rule of the policy holds.
+type DetectionPolicy struct {
+	Rules []DetectionRule `json:"rules" yaml:"rules"`
+}
+
+// DetectionRule is either a leaf rule matching detections or a combination of other rules.
+//
+// A detection hits a leaf rule when its class matches one of the classes, its score is
+// within [min_score, max_score] and its box area is within [min_box_area, max_box_area].
+// Zero bounds are not checked. Classes may end with "*" to match by prefix.
+// A leaf rule holds when no detection hits it, or when at least one does if require is set.
+//
+// A rule with all holds when all of its rules hold, a rule with any when at least one does.
+type DetectionRule struct {
+	Name       string          `json:"name" yaml:"name"`
+	Classes    []string        `json:"classes,omitempty" yaml:"classes,omitempty"`
+	MinScore   float64         `json:"min_score,omitempty" yaml:"min_score,omitempty"`
+	MaxScore   float64         `json:"max_score,omitempty" yaml:"max_score,omitempty"`
+	MinBoxArea int             `json:"min_box_area,omitempty" yaml:"min_box_area,omitempty"`
+	MaxBoxArea int             `json:"max_box_area,omitempty" yaml:"max_box_area,omitempty"`
+	Require    bool            `json:"require,omitempty" yaml:"require,omitempty"`
+	All        []DetectionRule `json:"all,omitempty" yaml:"all,omitempty"`
+	Any        []DetectionRule `json:"any,omitempty" yaml:"any,omitempty"`
+}
+
+// DefaultDetectionPolicy rejects images where the detector sees a man or explicit parts other than breasts.
+// Like the thresholds it replaced, it only rejects scores strictly above the threshold of the class.
+func DefaultDetectionPolicy() DetectionPolicy {
+	return DetectionPolicy{
+		Rules: []DetectionRule{
+			{Name: "no_female_genitalia_covered", Classes: []string{string(domain.DetectionClassFemaleGenitaliaCovered)}, MinScore: above(0.5)},
+			{Name: "no_female_genitalia_exposed", Classes: []string{string(domain.DetectionClassFemaleGenitaliaExposed)}, MinScore: above(0.3)},
+			{Name: "no_male_breast_exposed", Classes: []string{string(domain.DetectionClassMaleBreastExposed)}, MinScore: above(0.3)},
+			{Name: "no_anus_exposed", Classes: []string{string(domain.DetectionClassAnusExposed)}, MinScore: above(0.3)},
+			{Name: "no_face_male", Classes: []string{string(domain.DetectionClassFaceMale)}, MinScore: above(0.3)},
+			{Name: "no_male_genitalia_exposed", Classes: []string{string(domain.DetectionClassMaleGenitaliaExposed)}, MinScore: above(0.3)},
+			{Name: "no_anus_covered", Classes: []string{string(domain.DetectionClassAnusCovered)}, MinScore: above(0.5)},
+		},
+	}
+}
+
+// above returns the smallest score greater than the threshold, min_score bounds are inclusive.
+func above(threshold float64) float64 {
+	return math.Nextafter(threshold, math.Inf(1))
+}
+
+// LoadDetectionPolicy reads a policy file, YAML if its extension is .yaml or .yml and JSON otherwise.
+// Unknown fields are rejected. An empty path returns the default policy.
+func LoadDetectionPolicy(path string) (DetectionPolicy, error) {
+	if path == "" {
+		return DefaultDetectionPolicy(), nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return DetectionPolicy{}, err
+	}
+	defer file.Close()
+
+	var policy DetectionPolicy
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(file)
+		decoder.KnownFields(true)
+		err = decoder.Decode(&policy)
+	default:
+		decoder := json.NewDecoder(file)
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&policy)
+	}
+	if err != nil {
+		return DetectionPolicy{}, fmt.Errorf("decode %s: %w", path, err)
+	}
+	if err := policy.Validate(); err != nil {
+		return DetectionPolicy{}, fmt.Errorf("validate %s: %w", path, err)
+	}
+	return policy, nil
+}
+
+func (p DetectionPolicy) Validate() error {
+	if len(p.Rules) == 0 {
+		return errors.New("policy has no rules")
+	}
+	for _, rule := range p.Rules {
+		if err := rule.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Evaluate returns whether the detections pass the policy, and the name of the first rule that failed if not.
+func (p DetectionPolicy) Evaluate(detections []domain.Detection) (bool, string) {
+	for _, rule := range p.Rules {
+		if !rule.holds(detections) {
+			return false, rule.Name
+		}
+	}
+	return true, ""
+}
+
+func (r DetectionRule) validate() error {
+	if r.Name == "" {
+		return errors.New("rule without a name")
+	}
+
+	kinds := 0
+	for _, set := range []bool{len(r.Classes) > 0, len(r.All) > 0, len(r.Any) > 0} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return fmt.Errorf("rule %q needs exactly one of classes, all or any", r.Name)
+	}
+	if r.MaxScore > 0 && r.MinScore > r.MaxScore {
+		return fmt.Errorf("rule %q has min_score above max_score", r.Name)
+	}
+	if r.MaxBoxArea > 0 && r.MinBoxArea > r.MaxBoxArea {
+		return fmt.Errorf("rule %q has min_box_area above max_box_area", r.Name)
+	}
+
+	for _, rule := range append(r.All, r.Any...) {
+		if err := rule.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r DetectionRule) holds(detections []domain.Detection) bool {
+	switch {
+	case len(r.All) > 0:
+		for _, rule := range r.All {
+			if !rule.holds(detections) {
+				return false
+			}
+		}
+		return true
+	case len(r.Any) > 0:
+		for _, rule := range r.Any {
+			if rule.holds(detections) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, detection := range detections {
+		if r.hit(detection) {
+			return r.Require
+		}
+	}
+	return !r.Require
+}
+
+func (r DetectionRule) hit(detection domain.Detection) bool {
+	if !r.matchesClass(detection.Class) {
+		return false
+	}
+	if detection.Score < r.MinScore || (r.MaxScore > 0 && detection.Score > r.MaxScore) {
+		return false
+	}
+
+	if r.MinBoxArea > 0 || r.MaxBoxArea > 0 {
+		area := boxArea(detection.Box)
+		if area < r.MinBoxArea || (r.MaxBoxArea > 0 && area > r.MaxBoxArea) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r DetectionRule) matchesClass(class domain.DetectionClass) bool {
+	for _, pattern := range r.Classes {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(string(class), prefix) {
+				return true
+			}
+			continue
+		}
+		if string(class) == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// boxArea returns the area of a detector box given as [x, y, width, height].
+func boxArea(box []int) int {
+	if len(box) != 4 {
+		return 0
+	}
+	return box[2] * box[3]
+}
+
+// PolicyReport compares the stored outcome of detected tasks with a candidate policy.
+type PolicyReport struct {
+	Tasks int
+	// NowRejected are accepted tasks the policy would reject, NowAccepted the other way around.
+	NowRejected int
+	NowAccepted int
+	// Rules counts the rejections of the policy by the rule that failed.
+	Rules map[string]int
+}
+
+// TestDetectionPolicy re-evaluates the stored detector results of finished tasks against the policy.
+func (s *Service) TestDetectionPolicy(ctx context.Context, policy DetectionPolicy) (PolicyReport, error) {
+	report := PolicyReport{Rules: make(map[string]int)}
+
+	var afterID string
+	for {
+		tasks, err := s.taskService.GetDetectedTasks(ctx, afterID, backfillBatchSize)
+		if err != nil {
+			return PolicyReport{}, err
+		}
+
+		for _, task := range tasks {
+			report.Tasks++
+			accepted, rule := policy.Evaluate(task.DetectionResult.Detections)
+			if !accepted {
+				report.Rules[rule]++
+			}
+
+			wasAccepted := task.State == domain.TaskStateDone
+			switch {
+			case wasAccepted && !accepted:
+				report.NowRejected++
+			case !wasAccepted && accepted:
+				report.NowAccepted++
+			}
+		}
+
+		if len(tasks) < backfillBatchSize {
+			return report, nil
+		}
+		afterID = tasks[len(tasks)-1].ID
+	}
+}
@@ -0,0 +1,147 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/boobsrate/core/internal/domain"
+)
+
+func detection(class domain.DetectionClass, score float64, box ...int) domain.Detection {
+	return domain.Detection{Class: class, Score: score, Box: box}
+}
+
+func TestDetectionPolicyEvaluate(t *testing.T) {
+	breasts := DetectionRule{
+		Name:       "has_breasts",
+		Classes:    []string{"FEMALE_BREAST_*"},
+		MinScore:   0.4,
+		MinBoxArea: 2500,
+		Require:    true,
+	}
+	noMen := DetectionRule{
+		Name:     "no_men",
+		Classes:  []string{string(domain.DetectionClassFaceMale)},
+		MinScore: 0.3,
+		MaxScore: 0.9,
+	}
+	notCropped := DetectionRule{
+		Name: "not_cropped",
+		Any: []DetectionRule{
+			{Name: "has_face", Classes: []string{string(domain.DetectionClassFaceFemale)}, Require: true},
+			{Name: "has_belly", Classes: []string{"BELLY_*"}, Require: true},
+		},
+	}
+	noGenitalia := DetectionRule{
+		Name: "no_genitalia",
+		All: []DetectionRule{
+			{Name: "no_exposed", Classes: []string{string(domain.DetectionClassFemaleGenitaliaExposed)}, MinScore: 0.3},
+			{Name: "no_covered", Classes: []string{string(domain.DetectionClassFemaleGenitaliaCovered)}, MinScore: 0.5},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		rules      []DetectionRule
+		detections []domain.Detection
+		want       bool
+		wantRule   string
+	}{
+		{
+			name:       "required class by prefix",
+			rules:      []DetectionRule{breasts},
+			detections: []domain.Detection{detection(domain.DetectionClassFemaleBreastCovered, 0.8, 0, 0, 50, 50)},
+			want:       true,
+		},
+		{
+			name:     "required class missing",
+			rules:    []DetectionRule{breasts},
+			want:     false,
+			wantRule: "has_breasts",
+		},
+		{
+			name:       "required class below min_score",
+			rules:      []DetectionRule{breasts},
+			detections: []domain.Detection{detection(domain.DetectionClassFemaleBreastExposed, 0.39, 0, 0, 50, 50)},
+			want:       false,
+			wantRule:   "has_breasts",
+		},
+		{
+			name:       "required class with a too small box",
+			rules:      []DetectionRule{breasts},
+			detections: []domain.Detection{detection(domain.DetectionClassFemaleBreastExposed, 0.8, 0, 0, 10, 10)},
+			want:       false,
+			wantRule:   "has_breasts",
+		},
+		{
+			name:       "forbidden class at min_score",
+			rules:      []DetectionRule{noMen},
+			detections: []domain.Detection{detection(domain.DetectionClassFaceMale, 0.3)},
+			want:       false,
+			wantRule:   "no_men",
+		},
+		{
+			name:       "forbidden class above max_score",
+			rules:      []DetectionRule{noMen},
+			detections: []domain.Detection{detection(domain.DetectionClassFaceMale, 0.95)},
+			want:       true,
+		},
+		{
+			name:       "any holds when one rule does",
+			rules:      []DetectionRule{notCropped},
+			detections: []domain.Detection{detection(domain.DetectionClassBellyExposed, 0.5)},
+			want:       true,
+		},
+		{
+			name:     "any fails when no rule does",
+			rules:    []DetectionRule{notCropped},
+			want:     false,
+			wantRule: "not_cropped",
+		},
+		{
+			name:       "all fails when one rule does",
+			rules:      []DetectionRule{noGenitalia},
+			detections: []domain.Detection{detection(domain.DetectionClassFemaleGenitaliaCovered, 0.6)},
+			want:       false,
+			wantRule:   "no_genitalia",
+		},
+		{
+			name:       "first failing rule is reported",
+			rules:      []DetectionRule{noMen, breasts},
+			detections: []domain.Detection{detection(domain.DetectionClassFaceMale, 0.5)},
+			want:       false,
+			wantRule:   "no_men",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rule := DetectionPolicy{Rules: tt.rules}.Evaluate(tt.detections)
+			if got != tt.want || rule != tt.wantRule {
+				t.Fatalf("Evaluate() = %v, %q, want %v, %q", got, rule, tt.want, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestDefaultDetectionPolicyThresholds(t *testing.T) {
+	policy := DefaultDetectionPolicy()
+
+	tests := []struct {
+		name  string
+		score float64
+		want  bool
+	}{
+		{name: "below the threshold", score: 0.29, want: true},
+		{name: "at the threshold", score: 0.3, want: true},
+		{name: "above the threshold", score: 0.31, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := policy.Evaluate([]domain.Detection{detection(domain.DetectionClassFaceMale, tt.score)})
+			if got != tt.want {
+				t.Fatalf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,22 +2,36 @@ package parser
 
 import (
 	"context"
+	"time"
 
 	"github.com/boobsrate/core/internal/domain"
 )
 
 type DetectorService interface {
 	Detect(ctx context.Context, url string) (domain.DetectionResult, error)
+	DetectBytes(ctx context.Context, image []byte) (domain.DetectionResult, error)
+	WaitAvailable(ctx context.Context) error
 }
 
 type TitsService interface {
-	CreateTitsFromFile(ctx context.Context, filename, filePath string) error
-	CreateTitsFromBytes(ctx context.Context, filename string, file []byte, url string) error
+	CreateTitsFromBytes(ctx context.Context, filename string, file []byte, url string, meta domain.TitsMeta) error
+	SetDetections(ctx context.Context, titsID string, detections []domain.Detection) error
+	FindDuplicate(ctx context.Context, dhash uint64, maxDistance int) (domain.Tits, error)
+	GetOriginalTits(ctx context.Context) ([]domain.Tits, error)
+	GetImage(ctx context.Context, titsID string) ([]byte, error)
+	SetDHash(ctx context.Context, titsID string, dhash uint64) error
+	MergeDuplicate(ctx context.Context, originalID, duplicateID string) error
+	GetRenditionBackfill(ctx context.Context, afterID string, limit int) ([]domain.Tits, error)
+	CreateMissingRenditions(ctx context.Context, tits domain.Tits) (int, error)
 }
 
 type TaskRepo interface {
-	GetTask(ctx context.Context) (domain.Task, error)
+	ClaimTask(ctx context.Context, workerID string, lease time.Duration) (domain.Task, error)
+	ExtendLease(ctx context.Context, taskID, workerID string, lease time.Duration) error
+	SetTaskState(ctx context.Context, taskID, workerID string, state domain.TaskState) error
 	CreateTask(ctx context.Context, task []domain.Task) error
 	UpdateTask(ctx context.Context, task domain.Task) error
 	GetCountUnprocessedTasks(ctx context.Context) (int, error)
+	GetDetectionBackfill(ctx context.Context, afterID string, limit int) ([]domain.Task, error)
+	GetDetectedTasks(ctx context.Context, afterID string, limit int) ([]domain.Task, error)
 }
@@ -0,0 +1,27 @@
+package parser
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	tasksProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "parser",
+		Name:      "tasks_processed_total",
+		Help:      "Number of processed tasks by the state they ended up in.",
+	}, []string{"state"})
+
+	taskDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "parser",
+		Name:      "task_duration_seconds",
+		Help:      "Time spent processing a single task.",
+		Buckets:   prometheus.ExponentialBuckets(0.5, 2, 10),
+	})
+
+	busyWorkers = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "parser",
+		Name:      "busy_workers",
+		Help:      "Number of daemon workers processing a task.",
+	})
+)
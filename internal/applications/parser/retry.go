@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"errors"
+	"time"
+)
+
+type errorClass string
+
+const (
+	// errorClassTransient covers network failures, timeouts and 5xx/429 responses of image hosts.
+	errorClassTransient errorClass = "transient"
+	// errorClassDetector covers failures of the detection service.
+	errorClassDetector errorClass = "detector"
+	// errorClassUpload covers failures of storing the image.
+	errorClassUpload errorClass = "upload"
+	// errorClassPermanent covers errors that won't go away on retry, e.g. 404 or a too small image.
+	errorClassPermanent errorClass = "permanent"
+)
+
+type retryRule struct {
+	maxAttempts int
+}
+
+var retryRules = map[errorClass]retryRule{
+	errorClassTransient: {maxAttempts: 5},
+	errorClassDetector:  {maxAttempts: 3},
+	errorClassUpload:    {maxAttempts: 3},
+	errorClassPermanent: {maxAttempts: 1},
+}
+
+// maxAttempts is the most attempts any error class gets. A task claimed more often than that
+// kept crashing its worker or losing its lease, and is given up.
+func maxAttempts() int {
+	most := 0
+	for _, rule := range retryRules {
+		if rule.maxAttempts > most {
+			most = rule.maxAttempts
+		}
+	}
+	return most
+}
+
+type taskError struct {
+	class errorClass
+	err   error
+}
+
+func (e *taskError) Error() string {
+	return string(e.class) + ": " + e.err.Error()
+}
+
+func (e *taskError) Unwrap() error {
+	return e.err
+}
+
+func classify(class errorClass, err error) error {
+	return &taskError{class: class, err: err}
+}
+
+func errorClassOf(err error) errorClass {
+	var taskErr *taskError
+	if errors.As(err, &taskErr) {
+		return taskErr.class
+	}
+	return errorClassTransient
+}
+
+// shouldRetry reports whether a task that failed with err on the given attempt deserves another one.
+func shouldRetry(err error, attempt int) bool {
+	rule, ok := retryRules[errorClassOf(err)]
+	if !ok {
+		return false
+	}
+	return attempt < rule.maxAttempts
+}
+
+// backoff returns the delay before the next attempt, doubling with every attempt up to maxDelay.
+func backoff(attempt int, baseDelay, maxDelay time.Duration) time.Duration {
+	delay := baseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= maxDelay {
+			return maxDelay
+		}
+	}
+	return delay
+}
@@ -1,14 +1,14 @@
 package parser
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
-	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -23,6 +23,26 @@ type Service struct {
 	detectionService DetectorService
 	httpClient       *http.Client
 	httpProxyClient  *http.Client
+
+	// instanceID prefixes the IDs of the workers, which claim tasks under their own IDs.
+	instanceID string
+	cfg        Config
+	sources    []IngestionSource
+}
+
+type Config struct {
+	Workers      int
+	PollInterval time.Duration
+
+	LeaseDuration  time.Duration
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+
+	DetectionPolicy DetectionPolicy
+
+	DuplicateMode DuplicateMode
+	// DuplicateDistance is the max number of differing dhash bits of two images that are considered the same.
+	DuplicateDistance int
 }
 
 func NewService(
@@ -31,6 +51,8 @@ func NewService(
 	taskService TaskRepo,
 	detectionService DetectorService,
 	proxyUrl string,
+	cfg Config,
+	sources []IngestionSource,
 ) *Service {
 	proxyTransport := &http.Transport{
 		TLSHandshakeTimeout: 30 * time.Second,
@@ -41,6 +63,8 @@ func NewService(
 		proxyTransport.Proxy = http.ProxyURL(parsedProxyUrl)
 	}
 
+	hostname, _ := os.Hostname()
+
 	return &Service{
 		log:              log.Named("initiator"),
 		titsService:      titsService,
@@ -53,6 +77,9 @@ func NewService(
 			Timeout:   time.Second * 60,
 			Transport: proxyTransport,
 		},
+		instanceID: fmt.Sprintf("%s-%s", hostname, domain.NewID()),
+		cfg:        cfg,
+		sources:    sources,
 	}
 }
 
@@ -67,7 +94,7 @@ func (s *Service) run(withFill bool) {
 	ctx := context.Background()
 
 	if withFill {
-		s.runFillFormFiles()
+		s.Fill(ctx)
 	}
 
 	totalTasks, err := s.taskService.GetCountUnprocessedTasks(ctx)
@@ -76,258 +103,291 @@ func (s *Service) run(withFill bool) {
 		return
 	}
 
-	guard := make(chan struct{}, 50)
+	guard := make(chan struct{}, s.cfg.Workers)
 	wg := &sync.WaitGroup{}
 
 	for i := 0; i <= totalTasks; i++ {
-		task, err := s.taskService.GetTask(ctx)
+		if err := s.detectionService.WaitAvailable(ctx); err != nil {
+			break
+		}
+		// The slot is taken before the claim, so that the lease is kept from the moment the task is claimed.
+		guard <- struct{}{}
+		// Every claim gets its own worker ID, it's processed by its own goroutine.
+		task, err := s.taskService.ClaimTask(ctx, s.workerID(i), s.cfg.LeaseDuration)
+		if errors.Is(err, domain.ErrNoTasks) {
+			<-guard
+			break
+		}
 		if err != nil {
-			s.log.Error("get task", zap.Error(err))
+			<-guard
+			s.log.Error("claim task", zap.Error(err))
 			continue
 		}
 		s.log.Info("process idx", zap.Int("idx", i))
-		guard <- struct{}{}
 		wg.Add(1)
-		go s.work(ctx, wg, guard, i, task.Url, totalTasks, task)
+		go s.work(ctx, wg, guard, i, totalTasks, task)
 	}
 
 	wg.Wait()
 }
 
-func (s *Service) runFillFormFiles() {
+const createTasksBatchSize = 2000
+
+// Fill creates tasks from all configured ingestion sources.
+// A failing source is logged and skipped, the others still get their tasks created.
+func (s *Service) Fill(ctx context.Context) {
 	s.log.Info("Tits uploader started")
 	defer s.log.Info("Tits uploader stopped")
-	ctx := context.Background()
 
-	//guard := make(chan struct{}, 500)
-	//wg := &sync.WaitGroup{}
-	var allUrls []string
+	for _, source := range s.sources {
+		log := s.log.With(zap.String("source", source.Name()))
 
-	err := filepath.Walk("assets/urls/", func(path string, info os.FileInfo, err error) error {
-		if info.IsDir() {
-			return nil
-		}
-
-		file, err := os.Open(path)
+		tasks, err := source.Tasks(ctx)
 		if err != nil {
-			s.log.Error("open file", zap.Error(err))
-			return err
+			log.Error("read ingestion source", zap.Error(err))
+			continue
 		}
-		defer file.Close()
+		log.Info("Total urls", zap.Int("count", len(tasks)))
 
-		scanner := bufio.NewScanner(file)
-		for scanner.Scan() {
-			uri := scanner.Text()
-			allUrls = append(allUrls, uri)
+		for start := 0; start < len(tasks); start += createTasksBatchSize {
+			end := start + createTasksBatchSize
+			if end > len(tasks) {
+				end = len(tasks)
+			}
+			if err := s.taskService.CreateTask(ctx, tasks[start:end]); err != nil {
+				log.Error("create task: ", zap.Error(err))
+				continue
+			}
+			log.Info("Task created", zap.Int("index", end), zap.Int("total", len(tasks)))
 		}
+	}
+}
+
+func (s *Service) workerID(worker int) string {
+	return fmt.Sprintf("%s-%d", s.instanceID, worker)
+}
+
+// claimedBy returns the ID of the worker that claimed the task.
+func claimedBy(task domain.Task) string {
+	if task.ClaimedBy == nil {
+		return ""
+	}
+	return *task.ClaimedBy
+}
 
-		if err := scanner.Err(); err != nil {
-			s.log.Error("scan file", zap.Error(err))
-			return err
+// keepLease extends the lease of the task until the returned stop function is called.
+// If the lease is lost the work context is cancelled, another worker owns the task now.
+func (s *Service) keepLease(ctx context.Context, cancel context.CancelFunc, task domain.Task) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(s.cfg.LeaseDuration / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err := s.taskService.ExtendLease(ctx, task.ID, claimedBy(task), s.cfg.LeaseDuration)
+				if errors.Is(err, domain.ErrTaskLeaseLost) {
+					s.log.Warn("task lease lost", zap.String("task_id", task.ID))
+					cancel()
+					return
+				}
+				if err != nil {
+					s.log.Error("extend task lease", zap.String("task_id", task.ID), zap.Error(err))
+				}
+			}
 		}
+	}()
+	return func() { close(done) }
+}
 
-		return nil
-	})
-	if err != nil {
-		s.log.Error("walking directory assets/urls/", zap.Error(err))
-		os.Exit(1)
+const minImageSize = 200 * 1024
+
+func (s *Service) work(ctx context.Context, wg *sync.WaitGroup, guard chan struct{}, idx int, totalFiles int, task domain.Task) {
+	defer func() {
+		wg.Done()
+		<-guard
+	}()
+
+	s.handle(ctx, task, zap.Int("index", idx), zap.Int("total", totalFiles))
+}
+
+// handle processes a claimed task and stores its outcome, releasing the lease.
+func (s *Service) handle(ctx context.Context, task domain.Task, fields ...zap.Field) {
+	started := time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*90)
+	defer cancel()
+	defer s.keepLease(ctx, cancel, task)()
+
+	// The attempt was already counted by the claim, so that a worker crashing on the task uses it up.
+	log := s.log.With(
+		zap.String("url", task.Url),
+		zap.String("task_id", task.ID),
+		zap.Int("attempt", task.Attempts),
+	).With(fields...)
+	log.Info("Creating new tits")
+
+	var state domain.TaskState
+	var err error
+	if task.Attempts > maxAttempts() {
+		err = classify(errorClassPermanent, fmt.Errorf("no outcome stored after %d attempts", task.Attempts-1))
+	} else {
+		state, err = s.process(ctx, log, &task)
+	}
+	switch {
+	case err == nil:
+		task.State = state
+		task.NextAttemptAt = nil
+	case errors.Is(err, domain.ErrDetectorUnavailable):
+		// The task isn't to blame, put it back without using up an attempt.
+		task.Attempts--
+		task.State = domain.TaskStatePending
+		task.NextAttemptAt = nil
+		log.Warn("detector is unavailable, task returned to the queue")
+	case shouldRetry(err, task.Attempts):
+		nextAttemptAt := time.Now().UTC().Add(backoff(task.Attempts, s.cfg.RetryBaseDelay, s.cfg.RetryMaxDelay))
+		task.State = domain.TaskStateRetryScheduled
+		task.NextAttemptAt = &nextAttemptAt
+		task.Error = err.Error()
+		log.Warn("task failed, retry scheduled", zap.Time("next_attempt_at", nextAttemptAt), zap.Error(err))
+	default:
+		task.State = domain.TaskStateFailedPermanent
+		task.NextAttemptAt = nil
+		task.Error = err.Error()
+		log.Error("task failed permanently", zap.Error(err))
 	}
 
-	totalFiles := len(allUrls)
+	tasksProcessed.WithLabelValues(string(task.State)).Inc()
+	taskDuration.Observe(time.Since(started).Seconds())
 
-	s.log.Info("Total urls", zap.Int("count", totalFiles))
+	if err := s.taskService.UpdateTask(context.Background(), task); err != nil {
+		log.Error("update task", zap.Error(err))
+	}
+}
 
-	var tasks []domain.Task
+// process downloads the image of the task, runs it through detection and uploads it.
+// It returns the final state of a task that didn't fail.
+func (s *Service) process(ctx context.Context, log *zap.Logger, task *domain.Task) (domain.TaskState, error) {
+	if err := s.setState(ctx, task, domain.TaskStateDownloading); err != nil {
+		return "", err
+	}
+	b, err := s.download(ctx, task)
+	if err != nil {
+		return "", err
+	}
 
-	for idx := range allUrls {
-		// create tasks in batches of 2000
-		if len(tasks) >= 2000 {
-			err := s.taskService.CreateTask(ctx, tasks)
-			if err != nil {
-				s.log.Error("create task: ", zap.Error(err))
-			}
-			s.log.Info("Task created", zap.Int("index", idx), zap.Int("total", totalFiles))
-			tasks = []domain.Task{}
-		} else {
-			tasks = append(tasks, domain.Task{
-				ID:        domain.NewID(),
-				CreatedAt: time.Now(),
-				Processed: false,
-				Url:       allUrls[idx],
-				Status:    "",
-			})
-		}
+	dhash, duplicateOf, err := s.findDuplicate(ctx, log, b)
+	if err != nil {
+		return "", err
+	}
+	if duplicateOf != nil && s.cfg.DuplicateMode == DuplicateModeReject {
+		task.Error = fmt.Sprintf("Duplicate of %s", *duplicateOf)
+		return domain.TaskStateRejectedDuplicate, nil
 	}
 
-	err = s.taskService.CreateTask(ctx, tasks)
+	if err := s.setState(ctx, task, domain.TaskStateDetecting); err != nil {
+		return "", err
+	}
+	detectionResult, err := s.detectionService.DetectBytes(ctx, b)
 	if err != nil {
-		s.log.Error("create task: ", zap.Error(err))
+		return "", classify(errorClassDetector, err)
 	}
-}
+	task.DetectionResult = detectionResult
 
-func (s *Service) work(ctx context.Context, wg *sync.WaitGroup, guard chan struct{}, idx int, url string, totalFiles int, task domain.Task) {
-	ctx, cancel := context.WithTimeout(ctx, time.Second*90)
-	s.log.Info(
-		"Creating new tits",
-		zap.String("url", url),
-		zap.Int("index", idx),
-		zap.Int("total", totalFiles),
-	)
-
-	var res *http.Response
-	var err error
+	if ok, rule := s.cfg.DetectionPolicy.Evaluate(detectionResult.Detections); !ok {
+		log.Info("Detection policy rule failed", zap.String("rule", rule))
+		task.Error = fmt.Sprintf("Detection policy rule failed: %s", rule)
+		return domain.TaskStateRejectedByDetector, nil
+	}
 
-	detectionResult, err := s.detectionService.Detect(ctx, url)
+	if err := s.setState(ctx, task, domain.TaskStateUploading); err != nil {
+		return "", err
+	}
+	meta := titsMeta(task)
+	meta.DHash = dhash
+	meta.DuplicateOf = duplicateOf
+	err = s.titsService.CreateTitsFromBytes(ctx, fmt.Sprintf("%s.jpg", task.ID), b, remoteURL(task), meta)
 	if err != nil {
-		s.log.Error("Failed to detect content",
-			zap.String("url", url),
-			zap.Int("index", idx),
-			zap.Int("total", totalFiles),
-			zap.Error(err),
-		)
-		if task.NeedRetry {
-			s.log.Info("Detection failed, retrying",
-				zap.String("url", url),
-				zap.Int("index", idx),
-				zap.Int("total", totalFiles),
-			)
-		} else {
-			task.NeedRetry = true
-			s.log.Info("Detection failed, not retrying",
-				zap.String("url", url),
-				zap.Int("index", idx),
-				zap.Int("total", totalFiles),
-			)
-		}
-		task.Processed = false
-		task.Error = err.Error()
-		_ = s.taskService.UpdateTask(context.Background(), task)
-		cancel()
-		wg.Done()
-		<-guard
-		return
+		return "", classify(errorClassUpload, err)
 	}
 
-	var detectionThresholds = map[domain.DetectionClass]float64{
-		domain.DetectionClassFemaleGenitaliaCovered: 0.5,
-		domain.DetectionClassFemaleGenitaliaExposed: 0.3,
-		domain.DetectionClassMaleBreastExposed:      0.3,
-		domain.DetectionClassAnusExposed:            0.3,
-		domain.DetectionClassFaceMale:               0.3,
-		domain.DetectionClassMaleGenitaliaExposed:   0.3,
-		domain.DetectionClassAnusCovered:            0.5,
+	task.Error = ""
+	return domain.TaskStateDone, nil
+}
+
+// remoteURL returns the URL the image can be fetched from again, empty for local files.
+func remoteURL(task *domain.Task) string {
+	if strings.HasPrefix(task.Url, fileScheme) {
+		return ""
 	}
+	return task.Url
+}
 
-	task.DetectionResult = detectionResult
+func titsMeta(task *domain.Task) domain.TitsMeta {
+	return domain.TitsMeta{
+		Tags:       domain.TagsFromCategory(task.Category),
+		Detections: task.DetectionResult.Detections,
+	}
+}
 
-	if len(detectionResult.Detections) > 0 {
-		for _, detection := range detectionResult.Detections {
-			if threshold, ok := detectionThresholds[detection.Class]; ok {
-				if detection.Score > threshold {
-					s.log.Info("Detection score is above threshold",
-						zap.String("url", url),
-						zap.Int("index", idx),
-						zap.Int("total", totalFiles),
-						zap.String("detection_class", string(detection.Class)),
-						zap.Float64("score", detection.Score),
-					)
-					task.NeedRetry = false
-					task.DetectionResult = detectionResult
-					task.Processed = true
-					task.Error = fmt.Sprintf("Detection score is above threshold: %s > %f", detection.Class, threshold)
-					_ = s.taskService.UpdateTask(context.Background(), task)
-					cancel()
-					wg.Done()
-					<-guard
-					return
-				}
-			} else {
-				s.log.Info("Detection class not in thresholds",
-					zap.String("url", url),
-					zap.Int("index", idx),
-					zap.Int("total", totalFiles),
-					zap.String("detection_class", string(detection.Class)),
-					zap.Float64("score", detection.Score),
-				)
-			}
-		}
+// download fetches the image, retries go through the proxy. Local files are read from disk.
+func (s *Service) download(ctx context.Context, task *domain.Task) ([]byte, error) {
+	if path, ok := strings.CutPrefix(task.Url, fileScheme); ok {
+		return readImage(path)
 	}
 
-	if task.NeedRetry {
-		res, err = s.httpProxyClient.Get(url)
-	} else {
-		res, err = s.httpClient.Get(url)
+	client := s.httpClient
+	if task.Attempts > 1 {
+		client = s.httpProxyClient
 	}
 
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, task.Url, nil)
 	if err != nil {
-		s.log.Error("downloading image from URL", zap.Error(err), zap.String("url", url))
-		if !task.NeedRetry {
-			task.NeedRetry = true
-		} else {
-			task.Processed = true
-			task.Error = err.Error()
-		}
-		_ = s.taskService.UpdateTask(context.Background(), task)
-		cancel()
-		wg.Done()
-		<-guard
-		return
+		return nil, classify(errorClassPermanent, err)
 	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, classify(errorClassTransient, err)
+	}
+	defer res.Body.Close()
 
-	if res.StatusCode != 200 {
-		s.log.Error("downloading image from URL", zap.Error(err), zap.String("url", url), zap.Int("status", res.StatusCode))
-		if !task.NeedRetry {
-			task.NeedRetry = true
-		} else {
-			task.Processed = true
-			task.Error = fmt.Sprintf("Status code = %d", res.StatusCode)
+	if res.StatusCode != http.StatusOK {
+		err := fmt.Errorf("status code = %d", res.StatusCode)
+		if res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusRequestTimeout || res.StatusCode >= 500 {
+			return nil, classify(errorClassTransient, err)
 		}
-		_ = s.taskService.UpdateTask(context.Background(), task)
-		cancel()
-		wg.Done()
-		<-guard
-		return
+		return nil, classify(errorClassPermanent, err)
 	}
 
 	b, err := io.ReadAll(res.Body)
 	if err != nil {
-		if !task.NeedRetry {
-			task.NeedRetry = true
-		} else {
-			task.Processed = true
-			task.Error = err.Error()
-		}
-		_ = s.taskService.UpdateTask(context.Background(), task)
-		cancel()
-		wg.Done()
-		<-guard
+		return nil, classify(errorClassTransient, err)
 	}
-	_ = res.Body.Close()
-
-	// If 'b' size less than 400kb, return
-	if len(b) < 200*1024 {
-		task.Processed = true
-		task.Error = "image size less than 400kb"
-		_ = s.taskService.UpdateTask(context.Background(), task)
-		cancel()
-		wg.Done()
-		<-guard
-		return
+	if len(b) < minImageSize {
+		return nil, classify(errorClassPermanent, errors.New("image size less than 200kb"))
 	}
+	return b, nil
+}
 
-	err = s.titsService.CreateTitsFromBytes(ctx, fmt.Sprintf("%s.jpg", task.ID), b, url)
+func readImage(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
 	if err != nil {
-		s.log.Error("Failed to create tits",
-			zap.String("url", url),
-			zap.Int("index", idx),
-			zap.Int("total", totalFiles),
-			zap.Error(err),
-		)
-	}
-
-	task.Processed = true
-	_ = s.taskService.UpdateTask(context.Background(), task)
-	cancel()
-	wg.Done()
-	<-guard
+		return nil, classify(errorClassPermanent, err)
+	}
+	if len(b) < minImageSize {
+		return nil, classify(errorClassPermanent, errors.New("image size less than 200kb"))
+	}
+	return b, nil
+}
+
+func (s *Service) setState(ctx context.Context, task *domain.Task, state domain.TaskState) error {
+	task.State = state
+	if err := s.taskService.SetTaskState(ctx, task.ID, claimedBy(*task), state); err != nil {
+		return classify(errorClassTransient, err)
+	}
+	return nil
 }
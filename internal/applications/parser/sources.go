@@ -0,0 +1,307 @@
+package parser
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/boobsrate/core/internal/domain"
+)
+
+const fileScheme = "file://"
+
+// IngestionSource produces new tasks for the parser.
+type IngestionSource interface {
+	Name() string
+	Tasks(ctx context.Context) ([]domain.Task, error)
+}
+
+// NewSource builds a source from a "kind:location" spec, e.g. "urls:assets/urls/"
+// or "feed:https://example.com/feed.json". Supported kinds are urls, images, manifest and feed.
+func NewSource(spec string, httpClient *http.Client) (IngestionSource, error) {
+	kind, location, ok := strings.Cut(spec, ":")
+	if !ok || location == "" {
+		return nil, fmt.Errorf("invalid source spec %q", spec)
+	}
+
+	switch kind {
+	case "urls":
+		return &urlListSource{name: spec, dir: location}, nil
+	case "images":
+		return &imageDirSource{name: spec, dir: location}, nil
+	case "manifest":
+		return &manifestSource{name: spec, path: location}, nil
+	case "feed":
+		return &httpFeedSource{name: spec, url: location, client: httpClient}, nil
+	}
+	return nil, fmt.Errorf("unknown source kind %q", kind)
+}
+
+func newTask(source, category, url string, metadata map[string]string) domain.Task {
+	return domain.Task{
+		ID:        domain.NewID(),
+		CreatedAt: time.Now(),
+		State:     domain.TaskStatePending,
+		Url:       url,
+		Source:    source,
+		Category:  category,
+		Metadata:  metadata,
+	}
+}
+
+// urlListSource reads text files with one URL per line.
+// The directory a file lives in is used as the category.
+type urlListSource struct {
+	name string
+	dir  string
+}
+
+func (s *urlListSource) Name() string {
+	return s.name
+}
+
+func (s *urlListSource) Tasks(ctx context.Context) ([]domain.Task, error) {
+	var tasks []domain.Task
+	err := filepath.Walk(s.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("open %s: %w", path, err)
+		}
+		defer file.Close()
+
+		category := categoryOf(s.dir, path)
+		metadata := map[string]string{"list": strings.TrimSuffix(info.Name(), filepath.Ext(info.Name()))}
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			uri := strings.TrimSpace(scanner.Text())
+			if uri == "" {
+				continue
+			}
+			tasks = append(tasks, newTask(s.name, category, uri, metadata))
+		}
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("scan %s: %w", path, err)
+		}
+		return nil
+	})
+	return tasks, err
+}
+
+// imageExtensions are the files imageDirSource picks up, the formats the image pipeline decodes.
+var imageExtensions = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".webp": true,
+}
+
+// imageDirSource picks up JPEG, PNG and WebP files from a local directory, other files are ignored.
+// The directory a file lives in is used as the category.
+type imageDirSource struct {
+	name string
+	dir  string
+}
+
+func (s *imageDirSource) Name() string {
+	return s.name
+}
+
+func (s *imageDirSource) Tasks(ctx context.Context) ([]domain.Task, error) {
+	var tasks []domain.Task
+	err := filepath.Walk(s.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if !imageExtensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		tasks = append(tasks, newTask(s.name, categoryOf(s.dir, path), fileScheme+absPath, nil))
+		return nil
+	})
+	return tasks, err
+}
+
+func categoryOf(root, path string) string {
+	rel, err := filepath.Rel(root, filepath.Dir(path))
+	if err != nil || rel == "." {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// manifestEntry is a single item of a JSON manifest or feed.
+type manifestEntry struct {
+	URL      string            `json:"url"`
+	Category string            `json:"category"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// manifestSource reads a JSON array of manifest entries or a CSV file with a header.
+// The CSV needs a "url" column, a "category" column is optional and all other columns go to the metadata.
+type manifestSource struct {
+	name string
+	path string
+}
+
+func (s *manifestSource) Name() string {
+	return s.name
+}
+
+func (s *manifestSource) Tasks(ctx context.Context) ([]domain.Task, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []manifestEntry
+	switch strings.ToLower(filepath.Ext(s.path)) {
+	case ".json":
+		entries, err = decodeJSONEntries(file)
+	case ".csv":
+		entries, err = decodeCSVEntries(file)
+	default:
+		err = fmt.Errorf("unsupported manifest format %q", filepath.Ext(s.path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read manifest %s: %w", s.path, err)
+	}
+	return entriesToTasks(s.name, entries), nil
+}
+
+// httpFeedSource fetches manifest entries from a JSON endpoint.
+// The response is either an array of entries or an object with an "items" array.
+type httpFeedSource struct {
+	name   string
+	url    string
+	client *http.Client
+}
+
+func (s *httpFeedSource) Name() string {
+	return s.name
+}
+
+func (s *httpFeedSource) Tasks(ctx context.Context) ([]domain.Task, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch feed %s: unexpected status code: %d", s.url, resp.StatusCode)
+	}
+
+	entries, err := decodeJSONEntries(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("decode feed %s: %w", s.url, err)
+	}
+	return entriesToTasks(s.name, entries), nil
+}
+
+func decodeJSONEntries(r io.Reader) ([]manifestEntry, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []manifestEntry
+	if err := json.Unmarshal(raw, &entries); err == nil {
+		return entries, nil
+	}
+
+	var wrapped struct {
+		Items []manifestEntry `json:"items"`
+	}
+	if err := json.Unmarshal(raw, &wrapped); err != nil {
+		return nil, err
+	}
+	return wrapped.Items, nil
+}
+
+func decodeCSVEntries(r io.Reader) ([]manifestEntry, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	urlIdx, categoryIdx := -1, -1
+	for idx, column := range header {
+		switch strings.ToLower(strings.TrimSpace(column)) {
+		case "url":
+			urlIdx = idx
+		case "category":
+			categoryIdx = idx
+		}
+	}
+	if urlIdx < 0 {
+		return nil, fmt.Errorf("missing url column")
+	}
+
+	entries := make([]manifestEntry, 0, len(records)-1)
+	for _, record := range records[1:] {
+		entry := manifestEntry{
+			URL:      record[urlIdx],
+			Metadata: make(map[string]string),
+		}
+		for idx, value := range record {
+			switch idx {
+			case urlIdx:
+			case categoryIdx:
+				entry.Category = value
+			default:
+				if value != "" {
+					entry.Metadata[header[idx]] = value
+				}
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func entriesToTasks(source string, entries []manifestEntry) []domain.Task {
+	tasks := make([]domain.Task, 0, len(entries))
+	for _, entry := range entries {
+		uri := strings.TrimSpace(entry.URL)
+		if uri == "" {
+			continue
+		}
+		tasks = append(tasks, newTask(source, entry.Category, uri, entry.Metadata))
+	}
+	return tasks
+}
@@ -4,15 +4,37 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand"
+	"mime/multipart"
+	"net"
 	"net/http"
+	"time"
 
 	"github.com/boobsrate/core/internal/domain"
+	"github.com/boobsrate/core/pkg/breaker"
 )
 
+type Config struct {
+	// Timeout limits a single request, retries get their own timeout.
+	Timeout        time.Duration
+	MaxRetries     int
+	RetryBaseDelay time.Duration
+	// MaxConcurrent limits the number of requests in flight.
+	MaxConcurrent int
+	// BreakerThreshold is the number of consecutive failed calls that open the circuit breaker for BreakerCooldown.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
+	cfg        Config
+
+	slots   chan struct{}
+	breaker *breaker.Breaker
 }
 
 type detectRequest struct {
@@ -27,34 +49,147 @@ type detectResponse struct {
 	} `json:"detections"`
 }
 
-func NewClient(baseURL string) *Client {
+// statusError is returned for non-200 responses of the detector.
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.code)
+}
+
+func NewClient(baseURL string, cfg Config) *Client {
+	if cfg.MaxConcurrent < 1 {
+		cfg.MaxConcurrent = 1
+	}
 	return &Client{
-		baseURL:    baseURL,
-		httpClient: &http.Client{},
+		baseURL: baseURL,
+		httpClient: &http.Client{
+			Timeout: cfg.Timeout,
+		},
+		cfg:   cfg,
+		slots: make(chan struct{}, cfg.MaxConcurrent),
+		breaker: breaker.New(cfg.BreakerThreshold, cfg.BreakerCooldown, func(open bool) {
+			if open {
+				circuitOpen.Set(1)
+				return
+			}
+			circuitOpen.Set(0)
+		}),
 	}
 }
 
+// WaitAvailable blocks while the circuit breaker is open.
+func (c *Client) WaitAvailable(ctx context.Context) error {
+	return c.breaker.Wait(ctx)
+}
+
+// Detect lets the detector download and check the image at url.
 func (c *Client) Detect(ctx context.Context, url string) (domain.DetectionResult, error) {
-	reqBody := detectRequest{
-		URL: url,
+	jsonBody, err := json.Marshal(detectRequest{URL: url})
+	if err != nil {
+		return domain.DetectionResult{}, fmt.Errorf("marshal request: %w", err)
 	}
 
-	jsonBody, err := json.Marshal(reqBody)
+	return c.call(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/detect/url", bytes.NewReader(jsonBody))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+}
+
+// DetectBytes uploads the image to the detector as a multipart form.
+func (c *Client) DetectBytes(ctx context.Context, image []byte) (domain.DetectionResult, error) {
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	part, err := form.CreateFormFile("file", "image.jpg")
 	if err != nil {
-		return domain.DetectionResult{}, fmt.Errorf("marshal request: %w", err)
+		return domain.DetectionResult{}, fmt.Errorf("create form file: %w", err)
+	}
+	if _, err := part.Write(image); err != nil {
+		return domain.DetectionResult{}, fmt.Errorf("write form file: %w", err)
 	}
+	if err := form.Close(); err != nil {
+		return domain.DetectionResult{}, fmt.Errorf("close form: %w", err)
+	}
+	payload := body.Bytes()
 
-	req, err := http.NewRequestWithContext(
-		ctx,
-		http.MethodPost,
-		c.baseURL+"/detect/url",
-		bytes.NewReader(jsonBody),
-	)
+	return c.call(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/detect/file", bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		return req, nil
+	})
+}
+
+// call sends the request built by newRequest. Timeouts, network errors and 5xx responses
+// are retried with jittered backoff. Once the detector keeps failing the circuit breaker opens
+// and calls fail fast with domain.ErrDetectorUnavailable.
+func (c *Client) call(ctx context.Context, newRequest func(ctx context.Context) (*http.Request, error)) (domain.DetectionResult, error) {
+	if !c.breaker.Allow() {
+		requestErrors.WithLabelValues("circuit_open").Inc()
+		return domain.DetectionResult{}, domain.ErrDetectorUnavailable
+	}
+
+	var result domain.DetectionResult
+	var err error
+	for attempt := 0; attempt <= c.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if waitErr := sleep(ctx, c.retryDelay(attempt)); waitErr != nil {
+				break
+			}
+		}
+
+		result, err = c.callOnce(ctx, newRequest)
+		if err == nil || !retryable(err) {
+			break
+		}
+	}
+
+	// Client errors mean the detector is up but didn't like the image.
+	var statusErr *statusError
+	switch {
+	case err == nil, errors.As(err, &statusErr) && statusErr.code < http.StatusInternalServerError:
+		c.breaker.Success()
+	case ctx.Err() != nil:
+		c.breaker.Release()
+	default:
+		c.breaker.Failure()
+	}
+	return result, err
+}
+
+func (c *Client) callOnce(ctx context.Context, newRequest func(ctx context.Context) (*http.Request, error)) (domain.DetectionResult, error) {
+	select {
+	case c.slots <- struct{}{}:
+	case <-ctx.Done():
+		return domain.DetectionResult{}, ctx.Err()
+	}
+	defer func() { <-c.slots }()
+
+	inFlight.Inc()
+	defer inFlight.Dec()
+
+	started := time.Now()
+	result, err := c.do(ctx, newRequest)
+	class := errorClassOf(err)
+	requestDuration.WithLabelValues(class).Observe(time.Since(started).Seconds())
 	if err != nil {
-		return domain.DetectionResult{}, fmt.Errorf("create request: %w", err)
+		requestErrors.WithLabelValues(class).Inc()
 	}
+	return result, err
+}
 
-	req.Header.Set("Content-Type", "application/json")
+func (c *Client) do(ctx context.Context, newRequest func(ctx context.Context) (*http.Request, error)) (domain.DetectionResult, error) {
+	req, err := newRequest(ctx)
+	if err != nil {
+		return domain.DetectionResult{}, fmt.Errorf("create request: %w", err)
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -63,7 +198,7 @@ func (c *Client) Detect(ctx context.Context, url string) (domain.DetectionResult
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return domain.DetectionResult{}, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return domain.DetectionResult{}, &statusError{code: resp.StatusCode}
 	}
 
 	var response detectResponse
@@ -91,3 +226,53 @@ func (c *Client) Detect(ctx context.Context, url string) (domain.DetectionResult
 
 	return result, nil
 }
+
+// retryDelay returns a random delay up to the exponential backoff of the attempt.
+func (c *Client) retryDelay(attempt int) time.Duration {
+	delay := c.cfg.RetryBaseDelay << (attempt - 1)
+	if delay <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(delay)))
+}
+
+func retryable(err error) bool {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.code >= http.StatusInternalServerError || statusErr.code == http.StatusTooManyRequests
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// errorClassOf maps an error to the label used in metrics.
+func errorClassOf(err error) string {
+	var statusErr *statusError
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.As(err, &statusErr) && statusErr.code >= http.StatusInternalServerError:
+		return "status_5xx"
+	case errors.As(err, &statusErr):
+		return "status_4xx"
+	case errors.As(err, &netErr):
+		return "network"
+	}
+	return "other"
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
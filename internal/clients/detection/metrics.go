@@ -0,0 +1,33 @@
+package detection
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "detector",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of single requests to the detector by their outcome.",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 10),
+	}, []string{"outcome"})
+
+	requestErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "detector",
+		Name:      "errors_total",
+		Help:      "Number of failed requests to the detector by error class.",
+	}, []string{"class"})
+
+	inFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "detector",
+		Name:      "in_flight_requests",
+		Help:      "Number of requests to the detector in progress.",
+	})
+
+	circuitOpen = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "detector",
+		Name:      "circuit_open",
+		Help:      "Whether the circuit breaker to the detector is open.",
+	})
+)
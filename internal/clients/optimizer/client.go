@@ -0,0 +1,96 @@
+package optimizer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boobsrate/core/internal/domain"
+)
+
+const (
+	defaultTimeout = 30 * time.Second
+	outputFormat   = "webp"
+)
+
+// Client resizes images with the external image optimizer. The optimizer downloads the
+// image itself, so the client passes it the storage URL of the image.
+type Client struct {
+	endpoint   string
+	sourceURL  string
+	httpClient *http.Client
+}
+
+// NewClient returns a client of the optimizer at endpoint. sourceURL is the base URL of the bucket
+// as seen by the optimizer, e.g. http://minio.minio:9000/tits.
+func NewClient(endpoint, sourceURL string) *Client {
+	return &Client{
+		endpoint:  strings.TrimSuffix(endpoint, "/"),
+		sourceURL: strings.TrimSuffix(sourceURL, "/"),
+		httpClient: &http.Client{
+			Timeout: defaultTimeout,
+		},
+	}
+}
+
+// Process lets the optimizer resize the stored image, falling back to the original URL when that fails.
+func (c *Client) Process(ctx context.Context, src domain.ImageSource, width int) (domain.ProcessedImage, error) {
+	var sources []string
+	if src.Key != "" && c.sourceURL != "" {
+		sources = append(sources, c.sourceURL+"/"+src.Key)
+	}
+	if src.URL != "" {
+		sources = append(sources, src.URL)
+	}
+	if len(sources) == 0 {
+		return domain.ProcessedImage{}, errors.New("image has no source url")
+	}
+
+	var errs []error
+	for _, source := range sources {
+		image, err := c.optimize(ctx, source, width)
+		if err == nil {
+			return image, nil
+		}
+		errs = append(errs, fmt.Errorf("optimize %s: %w", source, err))
+	}
+	return domain.ProcessedImage{}, errors.Join(errs...)
+}
+
+func (c *Client) optimize(ctx context.Context, source string, width int) (domain.ProcessedImage, error) {
+	query := url.Values{}
+	query.Set("size", strconv.Itoa(width))
+	query.Set("format", outputFormat)
+	query.Set("src", source)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint+"/optimize?"+query.Encode(), nil)
+	if err != nil {
+		return domain.ProcessedImage{}, fmt.Errorf("create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return domain.ProcessedImage{}, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return domain.ProcessedImage{}, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "image/") {
+		return domain.ProcessedImage{}, fmt.Errorf("unexpected content type: %q", contentType)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return domain.ProcessedImage{}, fmt.Errorf("read response: %w", err)
+	}
+	return domain.ProcessedImage{Data: data, ContentType: contentType}, nil
+}
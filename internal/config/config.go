@@ -1,6 +1,9 @@
 package config
 
 import (
+	"errors"
+	"time"
+
 	"github.com/caarlos0/env/v6"
 )
 
@@ -14,6 +17,49 @@ type Configuration struct {
 	Minio      MinioConfig
 	Images     ImagesConfig
 	OpenAI     OpenAIConfig
+	Duel       DuelConfig
+	Telegram   TelegramConfig
+	Session    SessionConfig
+	Abyss      AbyssConfig
+}
+
+type AbyssConfig struct {
+	CheckInterval time.Duration `env:"ABYSS_CHECK_INTERVAL" envDefault:"10s"`
+	DryRun        bool          `env:"ABYSS_DRY_RUN" envDefault:"false"`
+	// Window limits the reports taken into account, zero means all reports.
+	Window time.Duration `env:"ABYSS_REPORTS_WINDOW" envDefault:"168h"`
+
+	// Threshold is the sum of report weights that sends a card to the abyss.
+	Threshold float64 `env:"ABYSS_REPORTS_THRESHOLD" envDefault:"3"`
+	// Ratio is the weighted reports per impression that sends a card to the abyss,
+	// zero disables the rule.
+	Ratio          float64 `env:"ABYSS_REPORTS_RATIO" envDefault:"0.05"`
+	MinImpressions int64   `env:"ABYSS_MIN_IMPRESSIONS" envDefault:"50"`
+	MinReports     float64 `env:"ABYSS_MIN_REPORTS" envDefault:"1.5"`
+
+	NewAccountAge    time.Duration `env:"ABYSS_NEW_ACCOUNT_AGE" envDefault:"72h"`
+	NewAccountWeight float64       `env:"ABYSS_NEW_ACCOUNT_WEIGHT" envDefault:"0.25"`
+
+	// RestoreVotes is the number of votes that restores a card from the abyss, zero disables auto-restoration.
+	RestoreVotes int `env:"ABYSS_RESTORE_VOTES" envDefault:"0"`
+}
+
+type SessionConfig struct {
+	AccessTTL  time.Duration `env:"SESSION_ACCESS_TTL" envDefault:"15m"`
+	RefreshTTL time.Duration `env:"SESSION_REFRESH_TTL" envDefault:"336h"`
+	// TrustedProxies are the CIDRs of the reverse proxies allowed to set X-Forwarded-For.
+	TrustedProxies []string `env:"SESSION_TRUSTED_PROXIES" envSeparator:"," envDefault:""`
+}
+
+type TelegramConfig struct {
+	BotToken   string        `env:"TELEGRAM_BOT_TOKEN"`
+	AuthMaxAge time.Duration `env:"TELEGRAM_AUTH_MAX_AGE" envDefault:"24h"`
+}
+
+type DuelConfig struct {
+	TokenTTL time.Duration `env:"DUEL_TOKEN_TTL" envDefault:"10m"`
+	// ImpressionsFlushInterval is how often the impressions of served pairs are written to the database.
+	ImpressionsFlushInterval time.Duration `env:"DUEL_IMPRESSIONS_FLUSH_INTERVAL" envDefault:"10s"`
 }
 
 type OpenAIConfig struct {
@@ -36,8 +82,14 @@ type ServerConfiguration struct {
 }
 
 type ImagesConfig struct {
-	PublicEndpoint    string `env:"IMAGES_PUBLIC_ENDPOINT" envDefault:"https://s3.boobsrate.com"`
-	OptimizerEndpoint string `env:"IMAGES_OPTIMIZER_ENDPOINT" envDefault:"https://img.optimizer.awkr.ru/"`
+	PublicEndpoint string `env:"IMAGES_PUBLIC_ENDPOINT" envDefault:"https://s3.boobsrate.com"`
+	// Processor resizes the previews: native or optimizer.
+	Processor          string `env:"IMAGES_PROCESSOR" envDefault:"native"`
+	Quality            int    `env:"IMAGES_QUALITY" envDefault:"85"`
+	OptimizerEndpoint  string `env:"IMAGES_OPTIMIZER_ENDPOINT" envDefault:"https://img.optimizer.awkr.ru/"`
+	OptimizerSourceURL string `env:"IMAGES_OPTIMIZER_SOURCE_URL" envDefault:"http://minio.minio:9000/tits"`
+	// Renditions are the "name:width" sizes stored for every card, "card" is used for the card URL.
+	Renditions []string `env:"IMAGES_RENDITIONS" envSeparator:"," envDefault:"thumb:160,card:350,large:1024,original"`
 }
 
 type MinioConfig struct {
@@ -66,5 +118,18 @@ func LoadConfiguration() (*Configuration, error) {
 	if err := env.Parse(&config); err != nil {
 		return nil, err
 	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
 	return &config, nil
 }
+
+func (c *Configuration) validate() error {
+	if c.Abyss.CheckInterval <= 0 {
+		return errors.New("ABYSS_CHECK_INTERVAL must be positive")
+	}
+	if c.Duel.ImpressionsFlushInterval <= 0 {
+		return errors.New("DUEL_IMPRESSIONS_FLUSH_INTERVAL must be positive")
+	}
+	return nil
+}
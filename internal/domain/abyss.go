@@ -0,0 +1,71 @@
+package domain
+
+import "time"
+
+type AbyssAction string
+
+const (
+	AbyssActionMove    AbyssAction = "move"
+	AbyssActionRestore AbyssAction = "restore"
+)
+
+// AbyssCandidate is a card together with the reports that may send it to the abyss.
+type AbyssCandidate struct {
+	Tits    Tits
+	Reports []AbyssReport
+}
+
+// AbyssReport is a report enriched with what is known about its author.
+type AbyssReport struct {
+	ReportID          string
+	Reason            ReportReason
+	CreatedAt         time.Time
+	ReporterID        string
+	ReporterCreatedAt time.Time
+	ReporterAccepted  int
+	ReporterRejected  int
+}
+
+// AbyssEvent records why a card was moved to or restored from the abyss.
+type AbyssEvent struct {
+	ID        string                 `json:"id"`
+	TitsID    string                 `json:"tits_id"`
+	Action    AbyssAction            `json:"action"`
+	Policy    string                 `json:"policy"`
+	ActorID   *string                `json:"actor_id,omitempty"`
+	Inputs    map[string]interface{} `json:"inputs,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// AbyssRestoreCandidate is a card in the abyss with the votes it collected there.
+type AbyssRestoreCandidate struct {
+	Tits  Tits
+	Votes int
+}
+
+type AppealStatus string
+
+const (
+	AppealStatusOpen    AppealStatus = "open"
+	AppealStatusGranted AppealStatus = "granted"
+	AppealStatusDenied  AppealStatus = "denied"
+)
+
+func (s AppealStatus) Valid() bool {
+	switch s {
+	case AppealStatusOpen, AppealStatusGranted, AppealStatusDenied:
+		return true
+	}
+	return false
+}
+
+type Appeal struct {
+	ID        string       `json:"id"`
+	TitsID    string       `json:"tits_id"`
+	OpenedBy  string       `json:"opened_by"`
+	Reason    string       `json:"reason"`
+	Status    AppealStatus `json:"status"`
+	CreatedAt time.Time    `json:"created_at"`
+	DecidedBy *string      `json:"decided_by,omitempty"`
+	DecidedAt *time.Time   `json:"decided_at,omitempty"`
+}
@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+// Duel is a pair of cards served to a viewer, votes are accepted only for served duels.
+type Duel struct {
+	ID        string    `json:"id"`
+	TitsIDs   []string  `json:"tits_ids"`
+	ViewerID  string    `json:"viewer_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Opponent returns the other card of the duel.
+func (d Duel) Opponent(titsID string) (string, bool) {
+	if len(d.TitsIDs) != 2 {
+		return "", false
+	}
+	switch titsID {
+	case d.TitsIDs[0]:
+		return d.TitsIDs[1], true
+	case d.TitsIDs[1]:
+		return d.TitsIDs[0], true
+	}
+	return "", false
+}
@@ -0,0 +1,25 @@
+package domain
+
+import "errors"
+
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrInvalidInput = errors.New("invalid input")
+
+	ErrDuelTokenInvalid = errors.New("duel token is invalid")
+	ErrDuelTokenUsed    = errors.New("duel token is already used")
+
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrForbidden       = errors.New("forbidden")
+	ErrSessionRevoked  = errors.New("session is revoked or expired")
+	ErrAlreadyVoted    = errors.New("already voted for this pair")
+	ErrAlreadyReported = errors.New("already reported")
+	ErrReportDecided   = errors.New("report is already decided")
+	ErrAppealExists    = errors.New("card already has an open appeal")
+	ErrAppealDecided   = errors.New("appeal is already decided")
+
+	ErrNoTasks       = errors.New("no claimable tasks")
+	ErrTaskLeaseLost = errors.New("task lease is lost")
+
+	ErrDetectorUnavailable = errors.New("detector is unavailable")
+)
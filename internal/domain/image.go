@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ImageSource is an image to process. Data holds the image itself, Key the name of the
+// object in the storage and URL where it was downloaded from. Processors use what they need.
+type ImageSource struct {
+	Data []byte
+	Key  string
+	URL  string
+}
+
+type ProcessedImage struct {
+	Data        []byte
+	ContentType string
+}
+
+const (
+	// RenditionOriginal is the name of the rendition that is the uploaded image itself.
+	RenditionOriginal = "original"
+	// RenditionCard is the name of the rendition the card URL points at.
+	RenditionCard = "card"
+)
+
+// RenditionSpec is a size cards are rendered in. Images are scaled down to Width, a zero Width keeps the original.
+type RenditionSpec struct {
+	Name  string
+	Width int
+}
+
+// ImageRendition is a stored rendition of a card image.
+type ImageRendition struct {
+	Key    string `json:"-"`
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Format string `json:"format"`
+}
+
+// ParseRenditionSpecs parses "name:width" specs like "thumb:160". The original rendition
+// may be given by its name alone. The card rendition is required, new cards have no other preview.
+func ParseRenditionSpecs(specs []string) ([]RenditionSpec, error) {
+	renditions := make([]RenditionSpec, 0, len(specs))
+	seen := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		name, widthValue, _ := strings.Cut(spec, ":")
+		width := 0
+		if widthValue != "" {
+			var err error
+			width, err = strconv.Atoi(widthValue)
+			if err != nil || width < 0 {
+				return nil, fmt.Errorf("invalid width of rendition %q", spec)
+			}
+		}
+		if !validRenditionName(name) {
+			return nil, fmt.Errorf("invalid rendition name %q", name)
+		}
+		if (name == RenditionOriginal) != (width == 0) {
+			return nil, fmt.Errorf("rendition %q needs a width, only %s keeps the original size", spec, RenditionOriginal)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate rendition %q", name)
+		}
+		seen[name] = true
+		renditions = append(renditions, RenditionSpec{Name: name, Width: width})
+	}
+	if !seen[RenditionCard] {
+		return nil, fmt.Errorf("rendition %q is required", RenditionCard)
+	}
+	return renditions, nil
+}
+
+// validRenditionName reports whether the name is safe to use in storage keys.
+func validRenditionName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,33 @@
+package domain
+
+import "time"
+
+type AuditAction string
+
+const (
+	AuditActionMoveToAbyss      AuditAction = "move_to_abyss"
+	AuditActionRestoreFromAbyss AuditAction = "restore_from_abyss"
+	AuditActionDeleteTits       AuditAction = "delete_tits"
+	AuditActionResetRating      AuditAction = "reset_rating"
+	AuditActionBanUser          AuditAction = "ban_user"
+	AuditActionUnbanUser        AuditAction = "unban_user"
+	AuditActionAcceptReport     AuditAction = "accept_report"
+	AuditActionRejectReport     AuditAction = "reject_report"
+	AuditActionGrantAppeal      AuditAction = "grant_appeal"
+	AuditActionDenyAppeal       AuditAction = "deny_appeal"
+)
+
+type AuditEntry struct {
+	ID        string                 `json:"id"`
+	ActorID   string                 `json:"actor_id"`
+	Action    AuditAction            `json:"action"`
+	TargetID  string                 `json:"target_id"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+type ReportedTits struct {
+	Tits
+	Reports        int       `json:"reports"`
+	LastReportedAt time.Time `json:"last_reported_at"`
+}
@@ -0,0 +1,37 @@
+package domain
+
+import "strings"
+
+// TokenTypeClaim tells the tokens signed with the shared key apart. Only session and anonymous
+// tokens authenticate API requests, realtime and duel tokens are rejected.
+const TokenTypeClaim = "typ"
+
+const (
+	TokenTypeSession   = "session"
+	TokenTypeAnonymous = "anonymous"
+	TokenTypeRealtime  = "realtime"
+)
+
+// AnonymousSubjectPrefix keeps anonymous subjects from colliding with user IDs.
+const AnonymousSubjectPrefix = "anon:"
+
+// NewAnonymousSubject returns a new subject for an anonymous get-token caller.
+func NewAnonymousSubject() string {
+	return AnonymousSubjectPrefix + NewID()
+}
+
+func IsAnonymousSubject(subject string) bool {
+	return strings.HasPrefix(subject, AnonymousSubjectPrefix) && len(subject) > len(AnonymousSubjectPrefix)
+}
+
+// Principal is the authenticated caller of a request.
+// Anonymous principals are identified by the subject of an /auth/get-token token and have no user record.
+type Principal struct {
+	UserID    string
+	SessionID string
+	Role      UserRole
+}
+
+func (p Principal) Anonymous() bool {
+	return p.Role == UserRoleAnonymous
+}
@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+type Session struct {
+	ID               string     `json:"id"`
+	UserID           string     `json:"user_id"`
+	RefreshTokenHash string     `json:"-"`
+	CreatedAt        time.Time  `json:"created_at"`
+	ExpiresAt        time.Time  `json:"expires_at"`
+	LastUsedAt       time.Time  `json:"last_used_at"`
+	RevokedAt        *time.Time `json:"revoked_at"`
+	UserAgent        string     `json:"user_agent"`
+	IP               string     `json:"ip"`
+}
+
+func (s Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// SessionTokens is a pair of a short-lived access token and a long-lived refresh token.
+type SessionTokens struct {
+	SessionID        string
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
@@ -1,16 +1,55 @@
 package domain
 
-import "time"
+import (
+	"strings"
+	"time"
+)
+
+type TaskState string
+
+const (
+	TaskStatePending            TaskState = "pending"
+	TaskStateDetecting          TaskState = "detecting"
+	TaskStateRejectedByDetector TaskState = "rejected_by_detector"
+	TaskStateDownloading        TaskState = "downloading"
+	TaskStateUploading          TaskState = "uploading"
+	TaskStateDone               TaskState = "done"
+	TaskStateFailedPermanent    TaskState = "failed_permanent"
+	TaskStateRetryScheduled     TaskState = "retry_scheduled"
+	TaskStateRejectedDuplicate  TaskState = "rejected_duplicate"
+)
+
+// TaskFinalStates are the states a task never leaves.
+var TaskFinalStates = []TaskState{
+	TaskStateDone,
+	TaskStateRejectedByDetector,
+	TaskStateFailedPermanent,
+	TaskStateRejectedDuplicate,
+}
+
+func (s TaskState) Final() bool {
+	for _, state := range TaskFinalStates {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
 
 type Task struct {
-	ID              string          `json:"id"`
-	CreatedAt       time.Time       `json:"created_at"`
-	Processed       bool            `json:"processed"`
-	NeedRetry       bool            `json:"need_retry"`
-	Error           string          `json:"error"`
-	Url             string          `json:"url"`
-	Status          string          `json:"status"`
-	DetectionResult DetectionResult `json:"detection_result,omitempty"`
+	ID              string            `json:"id"`
+	CreatedAt       time.Time         `json:"created_at"`
+	State           TaskState         `json:"state"`
+	Attempts        int               `json:"attempts"`
+	NextAttemptAt   *time.Time        `json:"next_attempt_at,omitempty"`
+	Error           string            `json:"error"`
+	Url             string            `json:"url"`
+	Source          string            `json:"source"`
+	Category        string            `json:"category"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+	DetectionResult DetectionResult   `json:"detection_result,omitempty"`
+	ClaimedBy       *string           `json:"claimed_by,omitempty"`
+	LeaseExpiresAt  *time.Time        `json:"lease_expires_at,omitempty"`
 }
 
 type DetectionResult struct {
@@ -45,3 +84,48 @@ const (
 	DetectionClassFemaleBreastCovered    DetectionClass = "FEMALE_BREAST_COVERED"
 	DetectionClassButtocksCovered        DetectionClass = "BUTTOCKS_COVERED"
 )
+
+// DetectionTagMinScore is the score a detection needs to turn into a card tag.
+const DetectionTagMinScore = 0.5
+
+const (
+	DetectionTagExplicit = "explicit"
+	DetectionTagCovered  = "covered"
+)
+
+// explicitDetectionClasses are the classes that make a card explicit: exposed breasts, genitalia, buttocks or anus.
+var explicitDetectionClasses = []DetectionClass{
+	DetectionClassFemaleBreastExposed,
+	DetectionClassFemaleGenitaliaExposed,
+	DetectionClassMaleGenitaliaExposed,
+	DetectionClassButtocksExposed,
+	DetectionClassAnusExposed,
+}
+
+// DetectionTags derives card tags from the detector results.
+// Exposed classes listed in explicitDetectionClasses tag the card as explicit, any covered class as covered.
+func DetectionTags(detections []Detection) []string {
+	var explicit, covered bool
+	for _, detection := range detections {
+		if detection.Score < DetectionTagMinScore {
+			continue
+		}
+		for _, class := range explicitDetectionClasses {
+			if detection.Class == class {
+				explicit = true
+			}
+		}
+		if strings.HasSuffix(string(detection.Class), "_COVERED") {
+			covered = true
+		}
+	}
+
+	var tags []string
+	if explicit {
+		tags = append(tags, DetectionTagExplicit)
+	}
+	if covered {
+		tags = append(tags, DetectionTagCovered)
+	}
+	return tags
+}
@@ -1,26 +1,109 @@
 package domain
 
 import (
+	"strings"
 	"time"
 )
 
 type Tits struct {
-	ID        string    `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	Rating    int64     `json:"rating"`
-	URL       string    `json:"url"`
-	FullURL   string    `json:"full_url"`
-	Abyss     bool      `json:"abyss"`
+	ID          string                    `json:"id"`
+	CreatedAt   time.Time                 `json:"created_at"`
+	Rating      int64                     `json:"rating"`
+	Score       float64                   `json:"score"`
+	Deviation   float64                   `json:"deviation"`
+	Volatility  float64                   `json:"volatility"`
+	URL         string                    `json:"url"`
+	FullURL     string                    `json:"full_url"`
+	Renditions  map[string]ImageRendition `json:"renditions,omitempty"`
+	Abyss       bool                      `json:"abyss"`
+	Impressions int64                     `json:"impressions"`
+	Tags        []string                  `json:"tags,omitempty"`
+	Detections  []Detection               `json:"detections,omitempty"`
+	DHash       *uint64                   `json:"-"`
+	DuplicateOf *string                   `json:"duplicate_of,omitempty"`
+}
+
+// TitsMeta is what the parser knows about a card before it is created.
+type TitsMeta struct {
+	Tags        []string
+	Detections  []Detection
+	DHash       *uint64
+	DuplicateOf *string
+}
+
+// TagsFromCategory turns a category path like "body-parts_upper-body_breasts_large" into tags.
+// The whole category is the first tag so that it can be selected as is, its segments
+// separated by "_" or "/" follow as broader tags.
+func TagsFromCategory(category string) []string {
+	category = strings.ToLower(strings.TrimSpace(category))
+	segments := strings.FieldsFunc(category, func(r rune) bool {
+		return r == '_' || r == '/'
+	})
+
+	tags := make([]string, 0, len(segments)+1)
+	seen := make(map[string]bool, len(segments)+1)
+	for _, tag := range append([]string{category}, segments...) {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
 }
 
 type Vote struct {
-	TitsID    string    `json:"tits_id"`
-	CreatedAt time.Time `json:"created_at"`
-	UserID    *string   `json:"user_id"`
+	TitsID     string    `json:"tits_id"`
+	OpponentID string    `json:"opponent_id"`
+	DuelID     string    `json:"duel_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	UserID     *string   `json:"user_id"`
+}
+
+type ReportReason string
+
+const (
+	ReportReasonNotAPerson ReportReason = "not_a_person"
+	ReportReasonDuplicate  ReportReason = "duplicate"
+	ReportReasonIllegal    ReportReason = "illegal"
+	ReportReasonLowQuality ReportReason = "low_quality"
+	ReportReasonOther      ReportReason = "other"
+)
+
+func (r ReportReason) Valid() bool {
+	switch r {
+	case ReportReasonNotAPerson, ReportReasonDuplicate, ReportReasonIllegal, ReportReasonLowQuality, ReportReasonOther:
+		return true
+	}
+	return false
+}
+
+type ReportStatus string
+
+const (
+	ReportStatusOpen     ReportStatus = "open"
+	ReportStatusAccepted ReportStatus = "accepted"
+	ReportStatusRejected ReportStatus = "rejected"
+)
+
+func (s ReportStatus) Valid() bool {
+	switch s {
+	case ReportStatusOpen, ReportStatusAccepted, ReportStatusRejected:
+		return true
+	}
+	return false
 }
 
 type Report struct {
-	TitsID    string    `json:"tits_id"`
-	CreatedAt time.Time `json:"created_at"`
-	UserID    *string   `json:"user_id"`
+	ID         string       `json:"id"`
+	TitsID     string       `json:"tits_id"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UserID     *string      `json:"user_id"`
+	Reason     ReportReason `json:"reason"`
+	Comment    string       `json:"comment,omitempty"`
+	Status     ReportStatus `json:"status"`
+	DecidedBy  *string      `json:"decided_by,omitempty"`
+	DecidedAt  *time.Time   `json:"decided_at,omitempty"`
+	ArchivedAt *time.Time   `json:"archived_at,omitempty"`
 }
@@ -0,0 +1,51 @@
+package domain
+
+import "time"
+
+type UserRole string
+
+const (
+	UserRoleAnonymous UserRole = "anonymous"
+	UserRoleUser      UserRole = "user"
+	UserRoleModerator UserRole = "moderator"
+	UserRoleAdmin     UserRole = "admin"
+)
+
+var userRoleRanks = map[UserRole]int{
+	UserRoleAnonymous: 0,
+	UserRoleUser:      1,
+	UserRoleModerator: 2,
+	UserRoleAdmin:     3,
+}
+
+// Includes reports whether the role grants at least the permissions of other, e.g. admin includes moderator.
+func (r UserRole) Includes(other UserRole) bool {
+	rank, ok := userRoleRanks[r]
+	if !ok {
+		return false
+	}
+	return rank >= userRoleRanks[other]
+}
+
+type User struct {
+	ID          string    `json:"id"`
+	TelegramID  int64     `json:"telegram_id"`
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name"`
+	AvatarURL   string    `json:"avatar_url"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+	Role        UserRole  `json:"role"`
+	Banned      bool      `json:"banned"`
+}
+
+type UserStats struct {
+	VotesCast    int   `json:"votes_cast"`
+	ReportsFiled int   `json:"reports_filed"`
+	ChatMessages int64 `json:"chat_messages"`
+}
+
+type UserProfile struct {
+	User
+	Stats UserStats `json:"stats"`
+}
@@ -34,8 +34,9 @@ func (w WSMessage) MarshalJSON() ([]byte, error) {
 }
 
 type WSNewRatingMessage struct {
-	TitsID    string `json:"tits_id"`
-	NewRating int64  `json:"new_rating"`
+	TitsID    string  `json:"tits_id"`
+	NewRating int64   `json:"new_rating"`
+	NewScore  float64 `json:"new_score"`
 }
 
 type WSOnlineUsersMessage struct {
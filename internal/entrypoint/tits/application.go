@@ -9,13 +9,23 @@ import (
 	"github.com/boobsrate/core/internal/applications/abyss"
 	"github.com/boobsrate/core/internal/config"
 	"github.com/boobsrate/core/internal/domain"
+	"github.com/boobsrate/core/internal/handlers"
+	abysshandlers "github.com/boobsrate/core/internal/handlers/abyss"
+	adminhandlers "github.com/boobsrate/core/internal/handlers/admin"
 	authhandlers "github.com/boobsrate/core/internal/handlers/auth"
 	"github.com/boobsrate/core/internal/handlers/chat"
 	titshandlers "github.com/boobsrate/core/internal/handlers/tits"
+	usershandlers "github.com/boobsrate/core/internal/handlers/users"
 	"github.com/boobsrate/core/internal/repository/postgres"
+	abysssvc "github.com/boobsrate/core/internal/services/abyss"
 	"github.com/boobsrate/core/internal/services/buryat"
 	"github.com/boobsrate/core/internal/services/centrifuge"
+	"github.com/boobsrate/core/internal/services/duel"
+	"github.com/boobsrate/core/internal/services/images"
+	"github.com/boobsrate/core/internal/services/moderation"
+	"github.com/boobsrate/core/internal/services/sessions"
 	titssvc "github.com/boobsrate/core/internal/services/tits"
+	userssvc "github.com/boobsrate/core/internal/services/users"
 	minio2 "github.com/boobsrate/core/internal/storage/minio"
 	"github.com/boobsrate/core/pkg/migrations"
 	"github.com/boobsrate/core/pkg/observer"
@@ -85,6 +95,11 @@ func Run() error {
 
 	database := postgres.NewPostgresDatabase(cfg.Database.DatabaseDSN)
 	titsRepo := postgres.NewTitsRepository(database)
+	usersRepo := postgres.NewUsersRepository(database)
+	sessionsRepo := postgres.NewSessionsRepository(database)
+	auditRepo := postgres.NewAuditRepository(database)
+	appealsRepo := postgres.NewAppealsRepository(database)
+	transactor := postgres.NewTransactor(database)
 
 	channel := "boobs_dev"
 
@@ -106,17 +121,56 @@ func Run() error {
 		return err
 	}
 
-	titsService := titssvc.NewService(titsRepo, minioStorage, logger, msgChan, cfg.Images.OptimizerEndpoint)
+	imageProcessor, err := images.NewProcessor(images.Config{
+		Kind:               cfg.Images.Processor,
+		Quality:            cfg.Images.Quality,
+		OptimizerEndpoint:  cfg.Images.OptimizerEndpoint,
+		OptimizerSourceURL: cfg.Images.OptimizerSourceURL,
+	})
+	if err != nil {
+		return err
+	}
+	renditions, err := domain.ParseRenditionSpecs(cfg.Images.Renditions)
+	if err != nil {
+		return err
+	}
+	titsService := titssvc.NewService(titsRepo, minioStorage, logger, msgChan, imageProcessor, renditions)
+
+	usersService := userssvc.NewService(usersRepo, logger)
+	sessionsService := sessions.NewService(sessionsRepo, centrifugeRunner, cfg.Centrifuge.SigningKey, cfg.Session, logger)
+
+	authMiddleware := handlers.NewAuthMiddleware(cfg.Centrifuge.SigningKey, sessionsService, usersService, logger)
+	authMiddleware.Apply(rootRouter)
 
-	titsHttpService := titshandlers.NewTitsHandler(titsService)
+	duelService := duel.NewService(cfg.Centrifuge.SigningKey, cfg.Duel.TokenTTL)
+
+	titsHttpService := titshandlers.NewTitsHandler(titsService, duelService)
 	titsHttpService.Register(rootRouter)
 
-	authhandler := authhandlers.NewAuthHandler(cfg.Centrifuge.SigningKey, cfg.Base.Env)
+	trustedProxies, err := authhandlers.ParseTrustedProxies(cfg.Session.TrustedProxies)
+	if err != nil {
+		return err
+	}
+	authhandler := authhandlers.NewAuthHandler(usersService, sessionsService, cfg.Centrifuge.SigningKey, cfg.Base.Env, cfg.Telegram, trustedProxies)
 	authhandler.Register(rootRouter)
 
-	chatHandler := chat.NewChatHandler(cfg.Centrifuge.SigningKey, cfg.Base.Env, msgChan)
+	chatHandler := chat.NewChatHandler(usersService, msgChan, logger)
 	chatHandler.Register(rootRouter)
 
+	usersHandler := usershandlers.NewUsersHandler(usersService)
+	usersHandler.Register(rootRouter)
+
+	abyssKeeper := abyss.NewKeeper(logger, titsService, cfg.Abyss, abyss.NewPolicies(cfg.Abyss))
+	moderationService := moderation.NewService(transactor, titsRepo, appealsRepo, usersRepo, auditRepo, minioStorage, sessionsService, abyssKeeper, logger)
+
+	adminHandler := adminhandlers.NewAdminHandler(moderationService)
+	adminHandler.Register(rootRouter)
+
+	abyssService := abysssvc.NewService(titsRepo, appealsRepo, logger)
+
+	abyssHandler := abysshandlers.NewAbyssHandler(abyssService)
+	abyssHandler.Register(rootRouter)
+
 	rootServer := &http.Server{
 		Addr:    fmt.Sprintf(":%d", cfg.Server.HTTPPort),
 		Handler: tracing.ApplyPrometheusMiddleware(server.ApplyCors(rootRouter), "titsbackend"),
@@ -124,8 +178,6 @@ func Run() error {
 
 	httpRootServer := server.NewGracefulServer(rootServer, logger.Named("http_server"))
 
-	abyssKeeper := abyss.NewKeeper(logger, titsService)
-
 	obs := observer.NewObserver()
 
 	obs.AddOpener(observer.OpenerFunc(func() error {
@@ -156,6 +208,10 @@ func Run() error {
 		abyssKeeper.Run(ctx)
 	})
 
+	obs.AddUpper(func(ctx context.Context) {
+		titsService.RunImpressionsFlusher(ctx, cfg.Duel.ImpressionsFlushInterval)
+	})
+
 	obs.AddUpper(func(ctx context.Context) {
 		select {
 		case <-ctx.Done():
@@ -0,0 +1,87 @@
+package abyss
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/boobsrate/core/internal/domain"
+	"github.com/boobsrate/core/internal/handlers"
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	baseHandler
+	abyss Service
+}
+
+func NewAbyssHandler(abyss Service) *Handler {
+	return &Handler{
+		abyss: abyss,
+	}
+}
+
+func (h *Handler) Register(router *mux.Router) {
+	router.HandleFunc("/tits/{cardID}/appeal", handlers.RequireRole(domain.UserRoleModerator, h.appeal)).Methods("POST")
+	router.HandleFunc("/tits/abyss/{cardID}/vote", handlers.RequireRole(domain.UserRoleUser, h.vote)).Methods("POST")
+}
+
+type appealPayload struct {
+	Reason string `json:"reason"`
+}
+
+func (h *Handler) appeal(w http.ResponseWriter, r *http.Request) {
+	cardID := mux.Vars(r)["cardID"]
+	if cardID == "" {
+		h.ErrorJSON(w, "", http.StatusBadRequest)
+		return
+	}
+
+	var payload appealPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		h.ErrorJSON(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	principal, _ := handlers.PrincipalFromContext(r.Context())
+
+	appeal, err := h.abyss.OpenAppeal(r.Context(), principal, cardID, payload.Reason)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	h.RespJSON(w, appeal, http.StatusCreated)
+}
+
+func (h *Handler) vote(w http.ResponseWriter, r *http.Request) {
+	cardID := mux.Vars(r)["cardID"]
+	if cardID == "" {
+		h.ErrorJSON(w, "", http.StatusBadRequest)
+		return
+	}
+
+	principal, _ := handlers.PrincipalFromContext(r.Context())
+
+	if err := h.abyss.Vote(r.Context(), principal, cardID); err != nil {
+		h.respondError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) respondError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		h.ErrorJSON(w, "card is not in the abyss", http.StatusNotFound)
+	case errors.Is(err, domain.ErrForbidden):
+		h.ErrorJSON(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, domain.ErrInvalidInput):
+		h.ErrorJSON(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrAppealExists), errors.Is(err, domain.ErrAlreadyVoted):
+		h.ErrorJSON(w, err.Error(), http.StatusConflict)
+	default:
+		h.ErrorJSON(w, "", http.StatusInternalServerError)
+	}
+}
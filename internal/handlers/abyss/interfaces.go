@@ -0,0 +1,12 @@
+package abyss
+
+import (
+	"context"
+
+	"github.com/boobsrate/core/internal/domain"
+)
+
+type Service interface {
+	OpenAppeal(ctx context.Context, principal domain.Principal, titsID, reason string) (domain.Appeal, error)
+	Vote(ctx context.Context, principal domain.Principal, titsID string) error
+}
@@ -0,0 +1,281 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/boobsrate/core/internal/domain"
+	"github.com/boobsrate/core/internal/handlers"
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+type Handler struct {
+	baseHandler
+	moderation ModerationService
+}
+
+func NewAdminHandler(moderation ModerationService) *Handler {
+	return &Handler{
+		moderation: moderation,
+	}
+}
+
+func (h *Handler) Register(router *mux.Router) {
+	admin := router.PathPrefix("/admin").Subrouter()
+
+	moderator := func(next http.HandlerFunc) http.HandlerFunc {
+		return handlers.RequireRole(domain.UserRoleModerator, next)
+	}
+	administrator := func(next http.HandlerFunc) http.HandlerFunc {
+		return handlers.RequireRole(domain.UserRoleAdmin, next)
+	}
+
+	admin.HandleFunc("/reports", moderator(h.listReported)).Methods("GET")
+	admin.HandleFunc("/reports/queue", moderator(h.listReportQueue)).Methods("GET")
+	admin.HandleFunc("/reports/{reportID}/accept", moderator(h.acceptReport)).Methods("POST")
+	admin.HandleFunc("/reports/{reportID}/reject", moderator(h.rejectReport)).Methods("POST")
+	admin.HandleFunc("/appeals", moderator(h.listAppeals)).Methods("GET")
+	admin.HandleFunc("/appeals/{appealID}/grant", moderator(h.grantAppeal)).Methods("POST")
+	admin.HandleFunc("/appeals/{appealID}/deny", moderator(h.denyAppeal)).Methods("POST")
+	admin.HandleFunc("/audit", moderator(h.listAudit)).Methods("GET")
+	admin.HandleFunc("/tits/{cardID}/abyss-history", moderator(h.abyssHistory)).Methods("GET")
+	admin.HandleFunc("/tits/{cardID}/abyss", moderator(h.moveToAbyss)).Methods("POST")
+	admin.HandleFunc("/tits/{cardID}/abyss", moderator(h.restoreFromAbyss)).Methods("DELETE")
+	admin.HandleFunc("/tits/{cardID}/reset-rating", moderator(h.resetRating)).Methods("POST")
+
+	admin.HandleFunc("/tits/{cardID}", administrator(h.deleteTits)).Methods("DELETE")
+	admin.HandleFunc("/users/{id}/ban", administrator(h.banUser)).Methods("POST")
+	admin.HandleFunc("/users/{id}/ban", administrator(h.unbanUser)).Methods("DELETE")
+}
+
+func (h *Handler) listReported(w http.ResponseWriter, r *http.Request) {
+	limit, ok := h.limit(w, r)
+	if !ok {
+		return
+	}
+
+	tits, err := h.moderation.GetReportedTits(r.Context(), limit)
+	if err != nil {
+		h.ErrorJSON(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	h.RespJSON(w, tits, http.StatusOK)
+}
+
+func (h *Handler) listReportQueue(w http.ResponseWriter, r *http.Request) {
+	limit, ok := h.limit(w, r)
+	if !ok {
+		return
+	}
+
+	status := domain.ReportStatus(r.URL.Query().Get("status"))
+	if status == "" {
+		status = domain.ReportStatusOpen
+	}
+
+	reports, err := h.moderation.GetReportQueue(r.Context(), status, limit)
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		h.ErrorJSON(w, "invalid status", http.StatusBadRequest)
+		return
+	case err != nil:
+		h.ErrorJSON(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	h.RespJSON(w, reports, http.StatusOK)
+}
+
+func (h *Handler) acceptReport(w http.ResponseWriter, r *http.Request) {
+	h.decideReport(w, r, true)
+}
+
+func (h *Handler) rejectReport(w http.ResponseWriter, r *http.Request) {
+	h.decideReport(w, r, false)
+}
+
+func (h *Handler) decideReport(w http.ResponseWriter, r *http.Request, accept bool) {
+	reportID := mux.Vars(r)["reportID"]
+	if reportID == "" {
+		h.ErrorJSON(w, "", http.StatusBadRequest)
+		return
+	}
+
+	principal, _ := handlers.PrincipalFromContext(r.Context())
+	report, err := h.moderation.DecideReport(r.Context(), principal, reportID, accept)
+	if err != nil {
+		h.respondAction(w, err)
+		return
+	}
+
+	h.RespJSON(w, report, http.StatusOK)
+}
+
+func (h *Handler) listAppeals(w http.ResponseWriter, r *http.Request) {
+	limit, ok := h.limit(w, r)
+	if !ok {
+		return
+	}
+
+	status := domain.AppealStatus(r.URL.Query().Get("status"))
+	if status == "" {
+		status = domain.AppealStatusOpen
+	}
+
+	appeals, err := h.moderation.GetAppeals(r.Context(), status, limit)
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		h.ErrorJSON(w, "invalid status", http.StatusBadRequest)
+		return
+	case err != nil:
+		h.ErrorJSON(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	h.RespJSON(w, appeals, http.StatusOK)
+}
+
+func (h *Handler) grantAppeal(w http.ResponseWriter, r *http.Request) {
+	h.decideAppeal(w, r, true)
+}
+
+func (h *Handler) denyAppeal(w http.ResponseWriter, r *http.Request) {
+	h.decideAppeal(w, r, false)
+}
+
+func (h *Handler) decideAppeal(w http.ResponseWriter, r *http.Request, grant bool) {
+	appealID := mux.Vars(r)["appealID"]
+	if appealID == "" {
+		h.ErrorJSON(w, "", http.StatusBadRequest)
+		return
+	}
+
+	principal, _ := handlers.PrincipalFromContext(r.Context())
+	appeal, err := h.moderation.DecideAppeal(r.Context(), principal, appealID, grant)
+	if err != nil {
+		h.respondAction(w, err)
+		return
+	}
+
+	h.RespJSON(w, appeal, http.StatusOK)
+}
+
+func (h *Handler) abyssHistory(w http.ResponseWriter, r *http.Request) {
+	cardID := mux.Vars(r)["cardID"]
+	if cardID == "" {
+		h.ErrorJSON(w, "", http.StatusBadRequest)
+		return
+	}
+
+	events, err := h.moderation.GetAbyssHistory(r.Context(), cardID)
+	if err != nil {
+		h.ErrorJSON(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	h.RespJSON(w, events, http.StatusOK)
+}
+
+func (h *Handler) listAudit(w http.ResponseWriter, r *http.Request) {
+	limit, ok := h.limit(w, r)
+	if !ok {
+		return
+	}
+
+	entries, err := h.moderation.GetAuditLog(r.Context(), r.URL.Query().Get("target_id"), limit)
+	if err != nil {
+		h.ErrorJSON(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	h.RespJSON(w, entries, http.StatusOK)
+}
+
+func (h *Handler) moveToAbyss(w http.ResponseWriter, r *http.Request) {
+	h.titsAction(w, r, h.moderation.MoveToAbyss)
+}
+
+func (h *Handler) restoreFromAbyss(w http.ResponseWriter, r *http.Request) {
+	h.titsAction(w, r, h.moderation.RestoreFromAbyss)
+}
+
+func (h *Handler) resetRating(w http.ResponseWriter, r *http.Request) {
+	h.titsAction(w, r, h.moderation.ResetRating)
+}
+
+func (h *Handler) deleteTits(w http.ResponseWriter, r *http.Request) {
+	h.titsAction(w, r, h.moderation.DeleteTits)
+}
+
+func (h *Handler) banUser(w http.ResponseWriter, r *http.Request) {
+	h.setBanned(w, r, true)
+}
+
+func (h *Handler) unbanUser(w http.ResponseWriter, r *http.Request) {
+	h.setBanned(w, r, false)
+}
+
+func (h *Handler) setBanned(w http.ResponseWriter, r *http.Request, banned bool) {
+	userID := mux.Vars(r)["id"]
+	if userID == "" {
+		h.ErrorJSON(w, "", http.StatusBadRequest)
+		return
+	}
+
+	principal, _ := handlers.PrincipalFromContext(r.Context())
+	h.respondAction(w, h.moderation.SetUserBanned(r.Context(), principal, userID, banned))
+}
+
+func (h *Handler) titsAction(
+	w http.ResponseWriter,
+	r *http.Request,
+	action func(ctx context.Context, actor domain.Principal, titsID string) error,
+) {
+	cardID := mux.Vars(r)["cardID"]
+	if cardID == "" {
+		h.ErrorJSON(w, "", http.StatusBadRequest)
+		return
+	}
+
+	principal, _ := handlers.PrincipalFromContext(r.Context())
+	h.respondAction(w, action(r.Context(), principal, cardID))
+}
+
+func (h *Handler) respondAction(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		h.ErrorJSON(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidInput):
+		h.ErrorJSON(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrReportDecided), errors.Is(err, domain.ErrAppealDecided):
+		h.ErrorJSON(w, err.Error(), http.StatusConflict)
+	case err != nil:
+		h.ErrorJSON(w, "", http.StatusInternalServerError)
+	default:
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (h *Handler) limit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	rawLimit := r.URL.Query().Get("limit")
+	if rawLimit == "" {
+		return defaultListLimit, true
+	}
+	limit, err := strconv.Atoi(rawLimit)
+	if err != nil || limit < 1 {
+		h.ErrorJSON(w, "invalid limit", http.StatusBadRequest)
+		return 0, false
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+	return limit, true
+}
@@ -0,0 +1,22 @@
+package admin
+
+import (
+	"context"
+
+	"github.com/boobsrate/core/internal/domain"
+)
+
+type ModerationService interface {
+	GetReportedTits(ctx context.Context, limit int) ([]domain.ReportedTits, error)
+	GetReportQueue(ctx context.Context, status domain.ReportStatus, limit int) ([]domain.Report, error)
+	DecideReport(ctx context.Context, actor domain.Principal, reportID string, accept bool) (domain.Report, error)
+	GetAppeals(ctx context.Context, status domain.AppealStatus, limit int) ([]domain.Appeal, error)
+	DecideAppeal(ctx context.Context, actor domain.Principal, appealID string, grant bool) (domain.Appeal, error)
+	GetAbyssHistory(ctx context.Context, titsID string) ([]domain.AbyssEvent, error)
+	GetAuditLog(ctx context.Context, targetID string, limit int) ([]domain.AuditEntry, error)
+	MoveToAbyss(ctx context.Context, actor domain.Principal, titsID string) error
+	RestoreFromAbyss(ctx context.Context, actor domain.Principal, titsID string) error
+	ResetRating(ctx context.Context, actor domain.Principal, titsID string) error
+	DeleteTits(ctx context.Context, actor domain.Principal, titsID string) error
+	SetUserBanned(ctx context.Context, actor domain.Principal, userID string, banned bool) error
+}
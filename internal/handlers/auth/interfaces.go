@@ -0,0 +1,19 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/boobsrate/core/internal/domain"
+)
+
+type UsersService interface {
+	LoginTelegram(ctx context.Context, user domain.User) (domain.User, error)
+}
+
+type SessionsService interface {
+	Create(ctx context.Context, userID, userAgent, ip string) (domain.SessionTokens, error)
+	Refresh(ctx context.Context, refreshToken string) (domain.SessionTokens, error)
+	Get(ctx context.Context, refreshToken string) (domain.Session, error)
+	Revoke(ctx context.Context, sessionID string) error
+	RevokeAll(ctx context.Context, userID string) error
+}
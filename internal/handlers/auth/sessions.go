@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/boobsrate/core/internal/domain"
+	"github.com/boobsrate/core/internal/handlers"
+)
+
+const refreshCookieName = "boobs_refresh"
+
+func (h *Handler) refresh(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(refreshCookieName)
+	if err != nil {
+		h.ErrorJSON(w, "no refresh token", http.StatusUnauthorized)
+		return
+	}
+
+	tokens, err := h.sessions.Refresh(r.Context(), cookie.Value)
+	switch {
+	case errors.Is(err, domain.ErrSessionRevoked):
+		h.clearSessionCookies(w)
+		h.ErrorJSON(w, err.Error(), http.StatusUnauthorized)
+		return
+	case err != nil:
+		h.ErrorJSON(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	h.setSessionCookies(w, tokens)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) logout(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.currentSession(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.sessions.Revoke(r.Context(), session.ID); err != nil {
+		h.ErrorJSON(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	h.clearSessionCookies(w)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) logoutAll(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.currentSession(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.sessions.RevokeAll(r.Context(), session.UserID); err != nil {
+		h.ErrorJSON(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	h.clearSessionCookies(w)
+	w.WriteHeader(http.StatusOK)
+}
+
+// currentSession returns the active session of the refresh cookie and writes an error response otherwise.
+func (h *Handler) currentSession(w http.ResponseWriter, r *http.Request) (domain.Session, bool) {
+	cookie, err := r.Cookie(refreshCookieName)
+	if err != nil {
+		h.ErrorJSON(w, "no refresh token", http.StatusUnauthorized)
+		return domain.Session{}, false
+	}
+
+	session, err := h.sessions.Get(r.Context(), cookie.Value)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		h.clearSessionCookies(w)
+		h.ErrorJSON(w, domain.ErrSessionRevoked.Error(), http.StatusUnauthorized)
+		return domain.Session{}, false
+	case err != nil:
+		h.ErrorJSON(w, "", http.StatusInternalServerError)
+		return domain.Session{}, false
+	case !session.Active(time.Now()):
+		h.clearSessionCookies(w)
+		h.ErrorJSON(w, domain.ErrSessionRevoked.Error(), http.StatusUnauthorized)
+		return domain.Session{}, false
+	}
+	return session, true
+}
+
+func (h *Handler) setSessionCookies(w http.ResponseWriter, tokens domain.SessionTokens) {
+	http.SetCookie(w, h.cookie(handlers.SessionCookieName, tokens.AccessToken, tokens.AccessExpiresAt))
+	http.SetCookie(w, h.cookie(refreshCookieName, tokens.RefreshToken, tokens.RefreshExpiresAt))
+}
+
+func (h *Handler) clearSessionCookies(w http.ResponseWriter) {
+	http.SetCookie(w, h.cookie(handlers.SessionCookieName, "", time.Unix(0, 0)))
+	http.SetCookie(w, h.cookie(refreshCookieName, "", time.Unix(0, 0)))
+}
+
+func (h *Handler) cookie(name, value string, expires time.Time) *http.Cookie {
+	cookieDomain := "dev.boobsrate.com"
+
+	if h.isProd {
+		cookieDomain = "boobsrate.com"
+	}
+
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Expires:  expires,
+		Domain:   cookieDomain,
+		Path:     "/",
+		HttpOnly: name == refreshCookieName,
+		Secure:   h.isProd,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// ParseTrustedProxies parses the CIDRs of the reverse proxies whose X-Forwarded-For header is trusted.
+func ParseTrustedProxies(cidrs []string) ([]*net.IPNet, error) {
+	proxies := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("parse trusted proxy %q: %w", cidr, err)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// clientIP returns the address of the client. X-Forwarded-For is only read when the request
+// comes from a trusted proxy, and then the last address not belonging to a trusted proxy is used,
+// since everything before it is supplied by the client.
+func (h *Handler) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !h.trustedProxy(host) {
+		return host
+	}
+
+	forwarded := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr := strings.TrimSpace(forwarded[i])
+		if addr == "" {
+			continue
+		}
+		if !h.trustedProxy(addr) {
+			return addr
+		}
+		host = addr
+	}
+	return host
+}
+
+func (h *Handler) trustedProxy(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, proxy := range h.trustedProxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	errTelegramNotConfigured = errors.New("telegram bot token is not configured")
+	errTelegramHashMissing   = errors.New("telegram hash is missing")
+	errTelegramHashInvalid   = errors.New("telegram hash is invalid")
+	errTelegramAuthExpired   = errors.New("telegram auth_date is too old")
+	errTelegramAuthInFuture  = errors.New("telegram auth_date is in the future")
+)
+
+// telegramClockSkew is how far auth_date may be ahead of our clock.
+const telegramClockSkew = time.Minute
+
+// verifyTelegramLogin checks the login widget payload as described in
+// https://core.telegram.org/widgets/login#checking-authorization.
+func verifyTelegramLogin(rawPayload []byte, botToken string, maxAge time.Duration, now time.Time) error {
+	if botToken == "" {
+		return errTelegramNotConfigured
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(rawPayload, &fields); err != nil {
+		return err
+	}
+
+	var hash string
+	if rawHash, ok := fields["hash"]; ok {
+		if err := json.Unmarshal(rawHash, &hash); err != nil {
+			return errTelegramHashInvalid
+		}
+	}
+	if hash == "" {
+		return errTelegramHashMissing
+	}
+
+	pairs := make([]string, 0, len(fields))
+	for key, raw := range fields {
+		if key == "hash" {
+			continue
+		}
+		value := string(raw)
+		var str string
+		if err := json.Unmarshal(raw, &str); err == nil {
+			value = str
+		}
+		if value == "" || value == "null" {
+			continue
+		}
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	dataCheckString := strings.Join(pairs, "\n")
+
+	secret := sha256.Sum256([]byte(botToken))
+	mac := hmac.New(sha256.New, secret[:])
+	mac.Write([]byte(dataCheckString)) // nolint: errcheck
+
+	expected, err := hex.DecodeString(hash)
+	if err != nil || !hmac.Equal(mac.Sum(nil), expected) {
+		return errTelegramHashInvalid
+	}
+
+	var payload struct {
+		AuthDate int64 `json:"auth_date"`
+	}
+	if err := json.Unmarshal(rawPayload, &payload); err != nil {
+		return err
+	}
+	authDate := time.Unix(payload.AuthDate, 0)
+	if authDate.Sub(now) > telegramClockSkew {
+		return errTelegramAuthInFuture
+	}
+	if now.Sub(authDate) > maxAge {
+		return errTelegramAuthExpired
+	}
+
+	return nil
+}
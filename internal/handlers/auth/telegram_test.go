@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testBotToken = "123456:test-token"
+
+// signTelegramLogin builds a widget payload signed with testBotToken.
+func signTelegramLogin(t *testing.T, fields map[string]interface{}) map[string]interface{} {
+	t.Helper()
+
+	pairs := make([]string, 0, len(fields))
+	for key, value := range fields {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", key, value))
+	}
+	sort.Strings(pairs)
+
+	secret := sha256.Sum256([]byte(testBotToken))
+	mac := hmac.New(sha256.New, secret[:])
+	mac.Write([]byte(strings.Join(pairs, "\n"))) // nolint: errcheck
+
+	signed := make(map[string]interface{}, len(fields)+1)
+	for key, value := range fields {
+		signed[key] = value
+	}
+	signed["hash"] = hex.EncodeToString(mac.Sum(nil))
+	return signed
+}
+
+func TestVerifyTelegramLogin(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	maxAge := 24 * time.Hour
+
+	payload := func(authDate time.Time) map[string]interface{} {
+		return signTelegramLogin(t, map[string]interface{}{
+			"id":         42,
+			"first_name": "Alice",
+			"username":   "alice",
+			"auth_date":  authDate.Unix(),
+		})
+	}
+
+	tests := []struct {
+		name    string
+		payload map[string]interface{}
+		want    error
+	}{
+		{
+			name:    "valid",
+			payload: payload(now.Add(-time.Hour)),
+		},
+		{
+			name:    "within clock skew",
+			payload: payload(now.Add(telegramClockSkew / 2)),
+		},
+		{
+			name: "tampered field",
+			payload: func() map[string]interface{} {
+				p := payload(now.Add(-time.Hour))
+				p["username"] = "mallory"
+				return p
+			}(),
+			want: errTelegramHashInvalid,
+		},
+		{
+			name: "missing hash",
+			payload: func() map[string]interface{} {
+				p := payload(now.Add(-time.Hour))
+				delete(p, "hash")
+				return p
+			}(),
+			want: errTelegramHashMissing,
+		},
+		{
+			name:    "expired",
+			payload: payload(now.Add(-maxAge - time.Second)),
+			want:    errTelegramAuthExpired,
+		},
+		{
+			name:    "in the future",
+			payload: payload(now.Add(telegramClockSkew + time.Second)),
+			want:    errTelegramAuthInFuture,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := json.Marshal(tt.payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = verifyTelegramLogin(raw, testBotToken, maxAge, now)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("verifyTelegramLogin() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -2,11 +2,16 @@ package auth
 
 import (
 	"encoding/json"
+	"errors"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/boobsrate/core/internal/config"
 	"github.com/boobsrate/core/internal/domain"
+	"github.com/boobsrate/core/internal/handlers"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/gorilla/mux"
 )
@@ -14,14 +19,24 @@ import (
 type Handler struct {
 	baseHandler
 
-	cfKey  string
-	isProd bool
+	users    UsersService
+	sessions SessionsService
+
+	cfKey    string
+	isProd   bool
+	telegram config.TelegramConfig
+
+	trustedProxies []*net.IPNet
 }
 
-func NewAuthHandler(centrifugeSignKey, env string) *Handler {
+func NewAuthHandler(users UsersService, sessions SessionsService, centrifugeSignKey, env string, telegram config.TelegramConfig, trustedProxies []*net.IPNet) *Handler {
 	return &Handler{
-		isProd: env == "prod",
-		cfKey:  centrifugeSignKey,
+		users:          users,
+		sessions:       sessions,
+		isProd:         env == "prod",
+		cfKey:          centrifugeSignKey,
+		telegram:       telegram,
+		trustedProxies: trustedProxies,
 	}
 }
 
@@ -37,7 +52,10 @@ type tgPayload struct {
 
 func (h *Handler) Register(router *mux.Router) {
 	router.HandleFunc("/auth/tg-login", h.tgLogin).Methods("POST")
-	router.HandleFunc("/auth/get-token", h.handleGetToken).Methods("GET")
+	router.HandleFunc("/auth/get-token", handlers.OptionalUser(h.handleGetToken)).Methods("GET")
+	router.HandleFunc("/auth/refresh", h.refresh).Methods("POST")
+	router.HandleFunc("/auth/logout", h.logout).Methods("POST")
+	router.HandleFunc("/auth/logout-all", h.logoutAll).Methods("POST")
 
 }
 
@@ -45,59 +63,72 @@ func (h *Handler) tgLogin(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 	jsonBody, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		h.ErrorJSON(w, err.Error(), 500)
+		h.ErrorJSON(w, "", http.StatusBadRequest)
+		return
 	}
 	var payload tgPayload
 	err = json.Unmarshal(jsonBody, &payload)
-	if err != nil {
-		h.ErrorJSON(w, err.Error(), 500)
+	if err != nil || payload.ID == 0 {
+		h.ErrorJSON(w, "invalid telegram payload", http.StatusBadRequest)
+		return
 	}
 
-	jwtData := map[string]interface{}{
-		"id":         payload.ID,
-		"first_name": payload.FirstName,
-		"last_name":  payload.LastName,
-		"username":   payload.Username,
-		"photo_url":  payload.PhotoUrl,
-		"auth_date":  payload.AuthDate,
-		"hash":       payload.Hash,
+	err = verifyTelegramLogin(jsonBody, h.telegram.BotToken, h.telegram.AuthMaxAge, time.Now())
+	switch {
+	case errors.Is(err, errTelegramNotConfigured):
+		h.ErrorJSON(w, "", http.StatusInternalServerError)
+		return
+	case errors.Is(err, errTelegramHashMissing):
+		h.ErrorJSON(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		h.ErrorJSON(w, "telegram authorization failed", http.StatusUnauthorized)
+		return
 	}
 
-	claims := jwt.MapClaims{
-		"exp": jwt.NewNumericDate(time.Now().Add(time.Hour)),
-		"iat": jwt.NewNumericDate(time.Now()),
-		"sub": jwtData,
+	user, err := h.users.LoginTelegram(r.Context(), domain.User{
+		TelegramID:  int64(payload.ID),
+		Username:    payload.Username,
+		DisplayName: strings.TrimSpace(payload.FirstName + " " + payload.LastName),
+		AvatarURL:   payload.PhotoUrl,
+	})
+	if err != nil {
+		h.ErrorJSON(w, "", http.StatusInternalServerError)
+		return
 	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenStr, _ := token.SignedString([]byte(h.cfKey))
-
-	cookieDomain := "dev.boobsrate.com"
-
-	if h.isProd {
-		cookieDomain = "boobsrate.com"
+	if user.Banned {
+		h.ErrorJSON(w, "user is banned", http.StatusForbidden)
+		return
 	}
-	expiration := time.Now().Add(14 * 24 * time.Hour)
-	cookie := http.Cookie{
-		Name:    "boobs_session",
-		Value:   tokenStr,
-		Expires: expiration,
-		Domain:  cookieDomain,
-		Path:    "/",
+
+	tokens, err := h.sessions.Create(r.Context(), user.ID, r.UserAgent(), h.clientIP(r))
+	if err != nil {
+		h.ErrorJSON(w, "", http.StatusInternalServerError)
+		return
 	}
-	http.SetCookie(w, &cookie)
-	w.WriteHeader(200)
+
+	h.setSessionCookies(w, tokens)
+	w.WriteHeader(http.StatusOK)
 }
 
 func (h *Handler) handleGetToken(w http.ResponseWriter, r *http.Request) {
 	// Send token back to frontend
 
-	id := domain.NewID()
+	// Logged in users connect under their own ID and the session ID as jti,
+	// so revoking the session also drops their realtime connection. Their token
+	// is for the realtime connection only, the API takes the session token.
+	// Anonymous callers also use the token as their identity in the API.
+	id, jti, tokenType := domain.NewAnonymousSubject(), domain.NewID(), domain.TokenTypeAnonymous
+	if principal, ok := handlers.PrincipalFromContext(r.Context()); ok && !principal.Anonymous() {
+		id, jti, tokenType = principal.UserID, principal.SessionID, domain.TokenTypeRealtime
+	}
 
 	customClaims := jwt.MapClaims{
-		"sub": id,
-		"exp": jwt.NewNumericDate(time.Now().Add(time.Hour)),
-		"iat": jwt.NewNumericDate(time.Now()),
+		"sub":                 id,
+		"jti":                 jti,
+		domain.TokenTypeClaim: tokenType,
+		"exp":                 jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		"iat":                 jwt.NewNumericDate(time.Now()),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, customClaims)
@@ -114,6 +145,7 @@ func (h *Handler) handleGetToken(w http.ResponseWriter, r *http.Request) {
 
 	customClaimsChan := jwt.MapClaims{
 		"sub":     id,
+		"jti":     jti,
 		"channel": channel,
 		"exp":     jwt.NewNumericDate(time.Now().Add(time.Hour)),
 		"iat":     jwt.NewNumericDate(time.Now()),
@@ -127,6 +159,7 @@ func (h *Handler) handleGetToken(w http.ResponseWriter, r *http.Request) {
 
 	customClaimsChatChan := jwt.MapClaims{
 		"sub":     id,
+		"jti":     jti,
 		"channel": chatChannel,
 		"exp":     jwt.NewNumericDate(time.Now().Add(time.Hour)),
 		"iat":     jwt.NewNumericDate(time.Now()),
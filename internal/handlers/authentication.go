@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/boobsrate/core/internal/domain"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+const SessionCookieName = "boobs_session"
+
+type principalCtxKey struct{}
+
+type SessionValidator interface {
+	Validate(ctx context.Context, sessionID string) error
+}
+
+type UserGetter interface {
+	GetUser(ctx context.Context, userID string) (domain.User, error)
+}
+
+// AuthMiddleware resolves the session cookie or the "Authorization: Bearer" token into a domain.Principal.
+// Requests without valid credentials pass through without a principal, use the Require* wrappers to reject them.
+type AuthMiddleware struct {
+	key      []byte
+	sessions SessionValidator
+	users    UserGetter
+	logger   *zap.Logger
+}
+
+func NewAuthMiddleware(signingKey string, sessions SessionValidator, users UserGetter, logger *zap.Logger) *AuthMiddleware {
+	return &AuthMiddleware{
+		key:      []byte(signingKey),
+		sessions: sessions,
+		users:    users,
+		logger:   logger.Named("auth_middleware"),
+	}
+}
+
+func (a *AuthMiddleware) Apply(router *mux.Router) {
+	router.Use(a.Handle)
+}
+
+func (a *AuthMiddleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := a.authenticate(r)
+		if ok {
+			r = r.WithContext(WithPrincipal(r.Context(), principal))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a *AuthMiddleware) authenticate(r *http.Request) (domain.Principal, bool) {
+	var tokenStr string
+	if bearer := r.Header.Get("Authorization"); strings.HasPrefix(bearer, "Bearer ") {
+		tokenStr = strings.TrimPrefix(bearer, "Bearer ")
+	} else if cookie, err := r.Cookie(SessionCookieName); err == nil {
+		tokenStr = cookie.Value
+	}
+	if tokenStr == "" {
+		return domain.Principal{}, false
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
+		return a.key, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return domain.Principal{}, false
+	}
+
+	subject, _ := claims["sub"].(string)
+	sessionID, _ := claims["sid"].(string)
+	tokenType, _ := claims[domain.TokenTypeClaim].(string)
+
+	// Other tokens signed with the key, like realtime and duel tokens, aren't credentials.
+	switch {
+	case tokenType == domain.TokenTypeAnonymous && sessionID == "" && domain.IsAnonymousSubject(subject):
+		return domain.Principal{UserID: subject, Role: domain.UserRoleAnonymous}, true
+	case tokenType == domain.TokenTypeSession && sessionID != "" && subject != "":
+	default:
+		return domain.Principal{}, false
+	}
+
+	ctx := r.Context()
+	if err := a.sessions.Validate(ctx, sessionID); err != nil {
+		if !errors.Is(err, domain.ErrSessionRevoked) {
+			a.logger.Error("validate session", zap.String("session_id", sessionID), zap.Error(err))
+		}
+		return domain.Principal{}, false
+	}
+
+	user, err := a.users.GetUser(ctx, subject)
+	if err != nil {
+		if !errors.Is(err, domain.ErrNotFound) {
+			a.logger.Error("get session user", zap.String("user_id", subject), zap.Error(err))
+		}
+		return domain.Principal{}, false
+	}
+	if user.Banned {
+		return domain.Principal{}, false
+	}
+
+	return domain.Principal{
+		UserID:    user.ID,
+		SessionID: sessionID,
+		Role:      user.Role,
+	}, true
+}
+
+func WithPrincipal(ctx context.Context, principal domain.Principal) context.Context {
+	return context.WithValue(ctx, principalCtxKey{}, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) (domain.Principal, bool) {
+	principal, ok := ctx.Value(principalCtxKey{}).(domain.Principal)
+	return principal, ok
+}
+
+// OptionalUser serves the request with or without a principal.
+// It only marks the route as one that reads PrincipalFromContext.
+func OptionalUser(next http.HandlerFunc) http.HandlerFunc {
+	return next
+}
+
+// RequireUser rejects requests without a principal, anonymous get-token subjects are accepted.
+func RequireUser(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := PrincipalFromContext(r.Context()); !ok {
+			authError(w, http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// RequireRole rejects requests of principals whose role doesn't include the given one.
+func RequireRole(role domain.UserRole, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := PrincipalFromContext(r.Context())
+		if !ok {
+			authError(w, http.StatusUnauthorized)
+			return
+		}
+		if !principal.Role.Includes(role) {
+			authError(w, http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func authError(w http.ResponseWriter, code int) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": strings.ToLower(http.StatusText(code))}) // nolint: errcheck
+}
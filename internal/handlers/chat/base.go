@@ -11,10 +11,10 @@ type baseHandler struct {
 func (b *baseHandler) ErrorJSON(w http.ResponseWriter, error string, code int) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(code)
 	if error != "" {
 		w.Write([]byte(`{"error":"` + error + `"}`)) // nolint: errcheck
 	}
-	w.WriteHeader(code)
 	return
 }
 
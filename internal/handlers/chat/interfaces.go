@@ -0,0 +1,12 @@
+package chat
+
+import (
+	"context"
+
+	"github.com/boobsrate/core/internal/domain"
+)
+
+type UsersService interface {
+	GetUser(ctx context.Context, userID string) (domain.User, error)
+	CountChatMessage(ctx context.Context, userID string) error
+}
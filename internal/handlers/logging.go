@@ -9,7 +9,7 @@ import (
 )
 
 type LoggingMiddleware struct {
-	logger *zap.Logger
+	logger *otelzap.Logger
 }
 
 func NewLoggingMiddleware(logger *zap.Logger) *LoggingMiddleware {
@@ -7,8 +7,13 @@ import (
 )
 
 type Service interface {
-	GetTits(ctx context.Context) ([]domain.Tits, error)
-	GetTop(ctx context.Context, limit int, abyss bool) ([]domain.Tits, error)
-	IncreaseRating(ctx context.Context, titsID string) error
-	Report(ctx context.Context, titsID string) error
+	GetTits(ctx context.Context, tag string) ([]domain.Tits, error)
+	GetTop(ctx context.Context, limit int, abyss bool, tag string) ([]domain.Tits, error)
+	IncreaseRating(ctx context.Context, vote domain.Vote) error
+	Report(ctx context.Context, report domain.Report) error
+}
+
+type DuelService interface {
+	Issue(viewerID string, tits []domain.Tits) (string, error)
+	Verify(token, viewerID string) (domain.Duel, error)
 }
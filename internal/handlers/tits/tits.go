@@ -1,28 +1,34 @@
 package tits
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
+	"github.com/boobsrate/core/internal/domain"
+	"github.com/boobsrate/core/internal/handlers"
 	"github.com/gorilla/mux"
 )
 
 type Handler struct {
 	baseHandler
-	tits Service
+	tits  Service
+	duels DuelService
 }
 
-func NewTitsHandler(tits Service) *Handler {
+func NewTitsHandler(tits Service, duels DuelService) *Handler {
 	return &Handler{
-		tits: tits,
+		tits:  tits,
+		duels: duels,
 	}
 }
 
 func (h *Handler) Register(router *mux.Router) {
-	router.HandleFunc("/tits", h.listTits).Methods("GET")
+	router.HandleFunc("/tits", handlers.OptionalUser(h.listTits)).Methods("GET")
 	router.HandleFunc("/tits/top/{limit}", h.listTopTits).Methods("GET")
-	router.HandleFunc("/tits/{cardID}", h.voteTits).Methods("POST")
-	router.HandleFunc("/tits/report/{cardID}", h.reportTits).Methods("POST")
+	router.HandleFunc("/tits/{cardID}", handlers.RequireUser(h.voteTits)).Methods("POST")
+	router.HandleFunc("/tits/report/{cardID}", handlers.RequireUser(h.reportTits)).Methods("POST")
 	router.HandleFunc("/tits/abyss/{limit}", h.listAbyssTits).Methods("GET")
 
 }
@@ -45,7 +51,7 @@ func (h *Handler) listAbyssTits(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tits, err := h.tits.GetTop(r.Context(), limit, true)
+	tits, err := h.tits.GetTop(r.Context(), limit, true, "")
 
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -55,6 +61,11 @@ func (h *Handler) listAbyssTits(w http.ResponseWriter, r *http.Request) {
 	h.RespJSON(w, tits, http.StatusOK)
 }
 
+type reportPayload struct {
+	Reason  domain.ReportReason `json:"reason"`
+	Comment string              `json:"comment"`
+}
+
 func (h *Handler) reportTits(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	cardID := vars["cardID"]
@@ -63,8 +74,28 @@ func (h *Handler) reportTits(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.tits.Report(r.Context(), cardID)
-	if err != nil {
+	var payload reportPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		h.ErrorJSON(w, "reason is required", http.StatusBadRequest)
+		return
+	}
+
+	principal, _ := handlers.PrincipalFromContext(r.Context())
+
+	err := h.tits.Report(r.Context(), domain.Report{
+		TitsID:  cardID,
+		UserID:  &principal.UserID,
+		Reason:  payload.Reason,
+		Comment: payload.Comment,
+	})
+	switch {
+	case errors.Is(err, domain.ErrAlreadyReported):
+		h.ErrorJSON(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, domain.ErrInvalidInput):
+		h.ErrorJSON(w, "invalid reason or comment", http.StatusBadRequest)
+		return
+	case err != nil:
 		h.ErrorJSON(w, "", http.StatusInternalServerError)
 		return
 	}
@@ -90,7 +121,7 @@ func (h *Handler) listTopTits(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tits, err := h.tits.GetTop(r.Context(), limit, false)
+	tits, err := h.tits.GetTop(r.Context(), limit, false, r.URL.Query().Get("tag"))
 
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -100,18 +131,36 @@ func (h *Handler) listTopTits(w http.ResponseWriter, r *http.Request) {
 	h.RespJSON(w, tits, http.StatusOK)
 }
 
+// duelTokenHeader carries the token of the duel served by GET /tits. The body stays a plain
+// array of cards so clients that don't vote keep working.
+const duelTokenHeader = "X-Duel-Token"
+
 func (h *Handler) listTits(w http.ResponseWriter, r *http.Request) {
 
-	tits, err := h.tits.GetTits(r.Context())
+	tits, err := h.tits.GetTits(r.Context(), r.URL.Query().Get("tag"))
 
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
+	if len(tits) == 2 {
+		principal, _ := handlers.PrincipalFromContext(r.Context())
+		duelToken, err := h.duels.Issue(principal.UserID, tits)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(duelTokenHeader, duelToken)
+	}
+
 	h.RespJSON(w, tits, http.StatusOK)
 }
 
+type votePayload struct {
+	DuelToken string `json:"duel_token"`
+}
+
 func (h *Handler) voteTits(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	cardID := vars["cardID"]
@@ -120,8 +169,43 @@ func (h *Handler) voteTits(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.tits.IncreaseRating(r.Context(), cardID)
+	var payload votePayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || payload.DuelToken == "" {
+		h.ErrorJSON(w, "duel_token is required", http.StatusBadRequest)
+		return
+	}
+
+	principal, _ := handlers.PrincipalFromContext(r.Context())
+
+	duel, err := h.duels.Verify(payload.DuelToken, principal.UserID)
 	if err != nil {
+		h.ErrorJSON(w, "invalid duel token", http.StatusForbidden)
+		return
+	}
+
+	opponentID, ok := duel.Opponent(cardID)
+	if !ok {
+		h.ErrorJSON(w, "card is not in the duel", http.StatusForbidden)
+		return
+	}
+
+	err = h.tits.IncreaseRating(r.Context(), domain.Vote{
+		TitsID:     cardID,
+		OpponentID: opponentID,
+		DuelID:     duel.ID,
+		UserID:     &principal.UserID,
+	})
+	switch {
+	case errors.Is(err, domain.ErrDuelTokenUsed), errors.Is(err, domain.ErrAlreadyVoted):
+		h.ErrorJSON(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, domain.ErrInvalidInput):
+		h.ErrorJSON(w, "", http.StatusBadRequest)
+		return
+	case errors.Is(err, domain.ErrNotFound):
+		h.ErrorJSON(w, "", http.StatusNotFound)
+		return
+	case err != nil:
 		h.ErrorJSON(w, "", http.StatusInternalServerError)
 		return
 	}
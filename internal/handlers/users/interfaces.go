@@ -0,0 +1,11 @@
+package users
+
+import (
+	"context"
+
+	"github.com/boobsrate/core/internal/domain"
+)
+
+type Service interface {
+	GetProfile(ctx context.Context, userID string) (domain.UserProfile, error)
+}
@@ -0,0 +1,85 @@
+package users
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/boobsrate/core/internal/domain"
+	"github.com/boobsrate/core/internal/handlers"
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	baseHandler
+	users Service
+}
+
+func NewUsersHandler(users Service) *Handler {
+	return &Handler{
+		users: users,
+	}
+}
+
+func (h *Handler) Register(router *mux.Router) {
+	router.HandleFunc("/me", handlers.RequireUser(h.getMe)).Methods("GET")
+	router.HandleFunc("/users/{id}", h.getUser).Methods("GET")
+}
+
+func (h *Handler) getMe(w http.ResponseWriter, r *http.Request) {
+	principal, _ := handlers.PrincipalFromContext(r.Context())
+	if principal.Anonymous() {
+		h.ErrorJSON(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	profile, err := h.users.GetProfile(r.Context(), principal.UserID)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		h.ErrorJSON(w, "unauthorized", http.StatusUnauthorized)
+		return
+	case err != nil:
+		h.ErrorJSON(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	h.RespJSON(w, profile, http.StatusOK)
+}
+
+// publicProfile is what anyone can see about a user. The telegram id, the ban
+// status and the last activity stay private.
+type publicProfile struct {
+	ID          string           `json:"id"`
+	Username    string           `json:"username"`
+	DisplayName string           `json:"display_name"`
+	AvatarURL   string           `json:"avatar_url"`
+	CreatedAt   time.Time        `json:"created_at"`
+	Stats       domain.UserStats `json:"stats"`
+}
+
+func (h *Handler) getUser(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["id"]
+	if userID == "" {
+		h.ErrorJSON(w, "", http.StatusBadRequest)
+		return
+	}
+
+	profile, err := h.users.GetProfile(r.Context(), userID)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		h.ErrorJSON(w, "", http.StatusNotFound)
+		return
+	case err != nil:
+		h.ErrorJSON(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	h.RespJSON(w, publicProfile{
+		ID:          profile.ID,
+		Username:    profile.Username,
+		DisplayName: profile.DisplayName,
+		AvatarURL:   profile.AvatarURL,
+		CreatedAt:   profile.CreatedAt,
+		Stats:       profile.Stats,
+	}, http.StatusOK)
+}
@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/boobsrate/core/internal/domain"
+	"github.com/uptrace/bun"
+)
+
+type abyssEventModel struct {
+	bun.BaseModel `bun:"table:abyss_history,alias:abyss_history,select:abyss_history"`
+
+	ID        string                 `bun:"id,pk"`
+	TitsID    string                 `bun:"tits_id"`
+	Action    string                 `bun:"action"`
+	Policy    string                 `bun:"policy"`
+	ActorID   *string                `bun:"actor_id"`
+	Inputs    map[string]interface{} `bun:"inputs,type:jsonb"`
+	CreatedAt time.Time              `bun:"created_at"`
+}
+
+func (a *abyssEventModel) FromDomain(event domain.AbyssEvent) {
+	a.ID = event.ID
+	a.TitsID = event.TitsID
+	a.Action = string(event.Action)
+	a.Policy = event.Policy
+	a.ActorID = event.ActorID
+	a.Inputs = event.Inputs
+	a.CreatedAt = event.CreatedAt
+}
+
+func abyssEventModelToDomain(model abyssEventModel) domain.AbyssEvent {
+	return domain.AbyssEvent{
+		ID:        model.ID,
+		TitsID:    model.TitsID,
+		Action:    domain.AbyssAction(model.Action),
+		Policy:    model.Policy,
+		ActorID:   model.ActorID,
+		Inputs:    model.Inputs,
+		CreatedAt: model.CreatedAt,
+	}
+}
+
+func abyssEventModelsToDomain(models []abyssEventModel) []domain.AbyssEvent {
+	events := make([]domain.AbyssEvent, 0, len(models))
+	for _, model := range models {
+		events = append(events, abyssEventModelToDomain(model))
+	}
+	return events
+}
+
+type abyssVoteModel struct {
+	bun.BaseModel `bun:"table:abyss_votes,alias:abyss_votes"`
+
+	TitsID    string    `bun:"tits_id,pk"`
+	UserID    string    `bun:"user_id,pk"`
+	CreatedAt time.Time `bun:"created_at"`
+}
+
+type abyssReportRow struct {
+	TitsID            string    `bun:"tits_id"`
+	ReportID          string    `bun:"report_id"`
+	Reason            string    `bun:"reason"`
+	CreatedAt         time.Time `bun:"created_at"`
+	ReporterID        string    `bun:"reporter_id"`
+	ReporterCreatedAt time.Time `bun:"reporter_created_at"`
+	ReporterAccepted  int       `bun:"reporter_accepted"`
+	ReporterRejected  int       `bun:"reporter_rejected"`
+}
+
+func abyssReportRowToDomain(row abyssReportRow) domain.AbyssReport {
+	return domain.AbyssReport{
+		ReportID:          row.ReportID,
+		Reason:            domain.ReportReason(row.Reason),
+		CreatedAt:         row.CreatedAt,
+		ReporterID:        row.ReporterID,
+		ReporterCreatedAt: row.ReporterCreatedAt,
+		ReporterAccepted:  row.ReporterAccepted,
+		ReporterRejected:  row.ReporterRejected,
+	}
+}
@@ -0,0 +1,53 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/boobsrate/core/internal/domain"
+	"github.com/uptrace/bun"
+)
+
+type appealModel struct {
+	bun.BaseModel `bun:"table:abyss_appeals,alias:abyss_appeals,select:abyss_appeals"`
+
+	ID        string     `bun:"id,pk"`
+	TitsID    string     `bun:"tits_id"`
+	OpenedBy  string     `bun:"opened_by"`
+	Reason    string     `bun:"reason"`
+	Status    string     `bun:"status"`
+	CreatedAt time.Time  `bun:"created_at"`
+	DecidedBy *string    `bun:"decided_by"`
+	DecidedAt *time.Time `bun:"decided_at"`
+}
+
+func (a *appealModel) FromDomain(appeal domain.Appeal) {
+	a.ID = appeal.ID
+	a.TitsID = appeal.TitsID
+	a.OpenedBy = appeal.OpenedBy
+	a.Reason = appeal.Reason
+	a.Status = string(appeal.Status)
+	a.CreatedAt = appeal.CreatedAt
+	a.DecidedBy = appeal.DecidedBy
+	a.DecidedAt = appeal.DecidedAt
+}
+
+func appealModelToDomain(model appealModel) domain.Appeal {
+	return domain.Appeal{
+		ID:        model.ID,
+		TitsID:    model.TitsID,
+		OpenedBy:  model.OpenedBy,
+		Reason:    model.Reason,
+		Status:    domain.AppealStatus(model.Status),
+		CreatedAt: model.CreatedAt,
+		DecidedBy: model.DecidedBy,
+		DecidedAt: model.DecidedAt,
+	}
+}
+
+func appealModelsToDomain(models []appealModel) []domain.Appeal {
+	appeals := make([]domain.Appeal, 0, len(models))
+	for _, model := range models {
+		appeals = append(appeals, appealModelToDomain(model))
+	}
+	return appeals
+}
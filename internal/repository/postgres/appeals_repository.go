@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/boobsrate/core/internal/domain"
+	"github.com/uptrace/bun"
+)
+
+const appealsOpenConstraint = "abyss_appeals_open_uniq"
+
+type AppealsRepository struct {
+	db *bun.DB
+}
+
+func NewAppealsRepository(db *bun.DB) *AppealsRepository {
+	return &AppealsRepository{
+		db: db,
+	}
+}
+
+func (r *AppealsRepository) CreateAppeal(ctx context.Context, appeal domain.Appeal) error {
+	model := appealModel{}
+	model.FromDomain(appeal)
+	_, err := r.db.NewInsert().
+		Model(&model).
+		Exec(ctx)
+	if isUniqueViolation(err, appealsOpenConstraint) {
+		return domain.ErrAppealExists
+	}
+	return err
+}
+
+func (r *AppealsRepository) GetAppeals(ctx context.Context, status domain.AppealStatus, limit int) ([]domain.Appeal, error) {
+	models := make([]appealModel, 0, limit)
+	err := r.db.NewSelect().
+		Model(&models).
+		Where("status = ?", status).
+		OrderExpr("created_at ASC").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return appealModelsToDomain(models), nil
+}
+
+// DecideAppeal moves an open appeal to the given status. Inside Transactor.RunInTx it joins the
+// transaction, so the restore of a granted appeal can be committed together with the decision.
+// It returns domain.ErrAppealDecided if the appeal was already decided.
+func (r *AppealsRepository) DecideAppeal(ctx context.Context, appealID string, status domain.AppealStatus, deciderID string) (domain.Appeal, error) {
+	var model appealModel
+	err := conn(ctx, r.db).RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		err := tx.NewSelect().
+			Model(&model).
+			Where("id = ?", appealID).
+			For("UPDATE").
+			Scan(ctx)
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if model.Status != string(domain.AppealStatusOpen) {
+			return domain.ErrAppealDecided
+		}
+
+		decidedAt := time.Now().UTC()
+		model.Status = string(status)
+		model.DecidedBy = &deciderID
+		model.DecidedAt = &decidedAt
+		_, err = tx.NewUpdate().
+			Model(&model).
+			Column("status", "decided_by", "decided_at").
+			WherePK().
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return domain.Appeal{}, err
+	}
+	return appealModelToDomain(model), nil
+}
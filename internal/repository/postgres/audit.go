@@ -0,0 +1,47 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/boobsrate/core/internal/domain"
+	"github.com/uptrace/bun"
+)
+
+type auditModel struct {
+	bun.BaseModel `bun:"table:audit_log,alias:audit_log,select:audit_log"`
+
+	ID        string                 `bun:"id,pk"`
+	ActorID   string                 `bun:"actor_id"`
+	Action    string                 `bun:"action"`
+	TargetID  string                 `bun:"target_id"`
+	Details   map[string]interface{} `bun:"details,type:jsonb"`
+	CreatedAt time.Time              `bun:"created_at"`
+}
+
+func (a *auditModel) FromDomain(entry domain.AuditEntry) {
+	a.ID = entry.ID
+	a.ActorID = entry.ActorID
+	a.Action = string(entry.Action)
+	a.TargetID = entry.TargetID
+	a.Details = entry.Details
+	a.CreatedAt = entry.CreatedAt
+}
+
+func auditModelToDomain(model auditModel) domain.AuditEntry {
+	return domain.AuditEntry{
+		ID:        model.ID,
+		ActorID:   model.ActorID,
+		Action:    domain.AuditAction(model.Action),
+		TargetID:  model.TargetID,
+		Details:   model.Details,
+		CreatedAt: model.CreatedAt,
+	}
+}
+
+func auditModelsToDomain(models []auditModel) []domain.AuditEntry {
+	entries := make([]domain.AuditEntry, 0, len(models))
+	for _, model := range models {
+		entries = append(entries, auditModelToDomain(model))
+	}
+	return entries
+}
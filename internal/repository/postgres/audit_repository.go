@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/boobsrate/core/internal/domain"
+	"github.com/uptrace/bun"
+)
+
+type AuditRepository struct {
+	db *bun.DB
+}
+
+func NewAuditRepository(db *bun.DB) *AuditRepository {
+	return &AuditRepository{
+		db: db,
+	}
+}
+
+func (r *AuditRepository) CreateAuditEntry(ctx context.Context, entry domain.AuditEntry) error {
+	model := auditModel{}
+	model.FromDomain(entry)
+	_, err := conn(ctx, r.db).NewInsert().
+		Model(&model).
+		Exec(ctx)
+	return err
+}
+
+func (r *AuditRepository) GetAuditLog(ctx context.Context, targetID string, limit int) ([]domain.AuditEntry, error) {
+	models := make([]auditModel, 0, limit)
+	query := r.db.NewSelect().
+		Model(&models).
+		OrderExpr("created_at DESC").
+		Limit(limit)
+	if targetID != "" {
+		query = query.Where("target_id = ?", targetID)
+	}
+	if err := query.Scan(ctx); err != nil {
+		return nil, err
+	}
+	return auditModelsToDomain(models), nil
+}
@@ -0,0 +1,18 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/boobsrate/core/internal/domain"
+	"github.com/uptrace/bun"
+)
+
+type detectionCacheModel struct {
+	bun.BaseModel `bun:"table:detection_cache,alias:detection_cache"`
+
+	Backend     string                 `bun:"backend,pk"`
+	ImageSHA256 string                 `bun:"image_sha256,pk"`
+	Result      domain.DetectionResult `bun:"result,type:jsonb"`
+	CreatedAt   time.Time              `bun:"created_at"`
+	ExpiresAt   time.Time              `bun:"expires_at"`
+}
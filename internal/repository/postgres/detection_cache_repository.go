@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/boobsrate/core/internal/domain"
+	"github.com/uptrace/bun"
+)
+
+type DetectionCacheRepository struct {
+	db *bun.DB
+}
+
+func NewDetectionCacheRepository(db *bun.DB) *DetectionCacheRepository {
+	return &DetectionCacheRepository{
+		db: db,
+	}
+}
+
+// GetDetection returns the result the backend cached for the image hash, domain.ErrNotFound if there is none or it expired.
+func (r *DetectionCacheRepository) GetDetection(ctx context.Context, backend, imageSHA256 string) (domain.DetectionResult, error) {
+	var model detectionCacheModel
+	err := r.db.NewSelect().
+		Model(&model).
+		Where("backend = ?", backend).
+		Where("image_sha256 = ?", imageSHA256).
+		Where("expires_at > ?", time.Now().UTC()).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.DetectionResult{}, domain.ErrNotFound
+	}
+	if err != nil {
+		return domain.DetectionResult{}, err
+	}
+	return model.Result, nil
+}
+
+func (r *DetectionCacheRepository) PutDetection(ctx context.Context, backend, imageSHA256 string, result domain.DetectionResult, ttl time.Duration) error {
+	now := time.Now().UTC()
+	model := detectionCacheModel{
+		Backend:     backend,
+		ImageSHA256: imageSHA256,
+		Result:      result,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
+	_, err := r.db.NewInsert().
+		Model(&model).
+		On("CONFLICT (backend, image_sha256) DO UPDATE").
+		Set("result = EXCLUDED.result").
+		Set("created_at = EXCLUDED.created_at").
+		Set("expires_at = EXCLUDED.expires_at").
+		Exec(ctx)
+	return err
+}
+
+// DeleteExpiredDetections removes expired cache entries and returns how many were removed.
+func (r *DetectionCacheRepository) DeleteExpiredDetections(ctx context.Context) (int64, error) {
+	res, err := r.db.NewDelete().
+		Model((*detectionCacheModel)(nil)).
+		Where("expires_at <= ?", time.Now().UTC()).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/boobsrate/core/internal/domain"
+	"github.com/uptrace/bun/driver/pgdriver"
+)
+
+const pgUniqueViolation = "23505"
+
+// isUniqueViolation reports whether err is a violation of the given unique constraint or index.
+func isUniqueViolation(err error, constraint string) bool {
+	var pgErr pgdriver.Error
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Field('C') == pgUniqueViolation && pgErr.Field('n') == constraint
+}
+
+// checkAffected returns domain.ErrNotFound if the statement didn't touch any rows.
+func checkAffected(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
@@ -10,18 +10,50 @@ import (
 type reportModel struct {
 	bun.BaseModel `bun:"table:reports"`
 
-	TitsID    string    `bun:"tits_id"`
-	CreatedAt time.Time `bun:"created_at"`
+	ID         string     `bun:"id,pk"`
+	TitsID     string     `bun:"tits_id"`
+	CreatedAt  time.Time  `bun:"created_at"`
+	UserID     *string    `bun:"user_id"`
+	Reason     string     `bun:"reason"`
+	Comment    string     `bun:"comment"`
+	Status     string     `bun:"status"`
+	DecidedBy  *string    `bun:"decided_by"`
+	DecidedAt  *time.Time `bun:"decided_at"`
+	ArchivedAt *time.Time `bun:"archived_at"`
 }
 
 func (v *reportModel) FromDomain(report domain.Report) {
+	v.ID = report.ID
 	v.CreatedAt = report.CreatedAt
 	v.TitsID = report.TitsID
+	v.UserID = report.UserID
+	v.Reason = string(report.Reason)
+	v.Comment = report.Comment
+	v.Status = string(report.Status)
+	v.DecidedBy = report.DecidedBy
+	v.DecidedAt = report.DecidedAt
+	v.ArchivedAt = report.ArchivedAt
 }
 
 func reportModelToDomain(model reportModel) domain.Report {
 	return domain.Report{
-		TitsID:    model.TitsID,
-		CreatedAt: model.CreatedAt,
+		ID:         model.ID,
+		TitsID:     model.TitsID,
+		CreatedAt:  model.CreatedAt,
+		UserID:     model.UserID,
+		Reason:     domain.ReportReason(model.Reason),
+		Comment:    model.Comment,
+		Status:     domain.ReportStatus(model.Status),
+		DecidedBy:  model.DecidedBy,
+		DecidedAt:  model.DecidedAt,
+		ArchivedAt: model.ArchivedAt,
 	}
 }
+
+func reportModelsToDomain(models []reportModel) []domain.Report {
+	reports := make([]domain.Report, 0, len(models))
+	for _, model := range models {
+		reports = append(reports, reportModelToDomain(model))
+	}
+	return reports
+}
@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/boobsrate/core/internal/domain"
+	"github.com/uptrace/bun"
+)
+
+type sessionModel struct {
+	bun.BaseModel `bun:"table:sessions,alias:sessions,select:sessions"`
+
+	ID               string     `bun:"id,pk"`
+	UserID           string     `bun:"user_id"`
+	RefreshTokenHash string     `bun:"refresh_token_hash"`
+	CreatedAt        time.Time  `bun:"created_at"`
+	ExpiresAt        time.Time  `bun:"expires_at"`
+	LastUsedAt       time.Time  `bun:"last_used_at"`
+	RevokedAt        *time.Time `bun:"revoked_at"`
+	UserAgent        string     `bun:"user_agent"`
+	IP               string     `bun:"ip"`
+}
+
+func (s *sessionModel) FromDomain(session domain.Session) {
+	s.ID = session.ID
+	s.UserID = session.UserID
+	s.RefreshTokenHash = session.RefreshTokenHash
+	s.CreatedAt = session.CreatedAt
+	s.ExpiresAt = session.ExpiresAt
+	s.LastUsedAt = session.LastUsedAt
+	s.RevokedAt = session.RevokedAt
+	s.UserAgent = session.UserAgent
+	s.IP = session.IP
+}
+
+func sessionModelToDomain(model sessionModel) domain.Session {
+	return domain.Session{
+		ID:               model.ID,
+		UserID:           model.UserID,
+		RefreshTokenHash: model.RefreshTokenHash,
+		CreatedAt:        model.CreatedAt,
+		ExpiresAt:        model.ExpiresAt,
+		LastUsedAt:       model.LastUsedAt,
+		RevokedAt:        model.RevokedAt,
+		UserAgent:        model.UserAgent,
+		IP:               model.IP,
+	}
+}
+
+func sessionModelsToDomain(models []sessionModel) []domain.Session {
+	sessions := make([]domain.Session, 0, len(models))
+	for _, model := range models {
+		sessions = append(sessions, sessionModelToDomain(model))
+	}
+	return sessions
+}
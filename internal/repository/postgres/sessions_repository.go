@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/boobsrate/core/internal/domain"
+	"github.com/uptrace/bun"
+)
+
+type SessionsRepository struct {
+	db *bun.DB
+}
+
+func NewSessionsRepository(db *bun.DB) *SessionsRepository {
+	return &SessionsRepository{
+		db: db,
+	}
+}
+
+func (r *SessionsRepository) CreateSession(ctx context.Context, session domain.Session) error {
+	model := sessionModel{}
+	model.FromDomain(session)
+	_, err := r.db.NewInsert().
+		Model(&model).
+		Exec(ctx)
+	return err
+}
+
+func (r *SessionsRepository) GetSession(ctx context.Context, sessionID string) (domain.Session, error) {
+	var model sessionModel
+	err := r.db.NewSelect().
+		Model(&model).
+		Where("id = ?", sessionID).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Session{}, domain.ErrNotFound
+	}
+	if err != nil {
+		return domain.Session{}, err
+	}
+	return sessionModelToDomain(model), nil
+}
+
+func (r *SessionsRepository) GetSessionByRefreshHash(ctx context.Context, refreshHash string) (domain.Session, error) {
+	var model sessionModel
+	err := r.db.NewSelect().
+		Model(&model).
+		Where("refresh_token_hash = ?", refreshHash).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Session{}, domain.ErrNotFound
+	}
+	if err != nil {
+		return domain.Session{}, err
+	}
+	return sessionModelToDomain(model), nil
+}
+
+// RotateRefreshToken replaces the refresh token of an active session.
+// It fails with domain.ErrSessionRevoked if the old token was already rotated or the session is not active.
+func (r *SessionsRepository) RotateRefreshToken(ctx context.Context, sessionID, oldHash, newHash string, expiresAt time.Time) error {
+	res, err := r.db.NewUpdate().
+		Model((*sessionModel)(nil)).
+		Set("refresh_token_hash = ?", newHash).
+		Set("expires_at = ?", expiresAt).
+		Set("last_used_at = ?", time.Now().UTC()).
+		Where("id = ?", sessionID).
+		Where("refresh_token_hash = ?", oldHash).
+		Where("revoked_at IS NULL").
+		Where("expires_at > NOW()").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return domain.ErrSessionRevoked
+	}
+	return nil
+}
+
+func (r *SessionsRepository) RevokeSession(ctx context.Context, sessionID string) error {
+	_, err := r.db.NewUpdate().
+		Model((*sessionModel)(nil)).
+		Set("revoked_at = ?", time.Now().UTC()).
+		Where("id = ?", sessionID).
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+	return err
+}
+
+// RevokeUserSessions revokes all active sessions of the user and returns them.
+func (r *SessionsRepository) RevokeUserSessions(ctx context.Context, userID string) ([]domain.Session, error) {
+	var models []sessionModel
+	_, err := r.db.NewUpdate().
+		Model((*sessionModel)(nil)).
+		Set("revoked_at = ?", time.Now().UTC()).
+		Where("user_id = ?", userID).
+		Where("revoked_at IS NULL").
+		Returning("*").
+		Exec(ctx, &models)
+	if err != nil {
+		return nil, err
+	}
+	return sessionModelsToDomain(models), nil
+}
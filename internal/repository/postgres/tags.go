@@ -0,0 +1,21 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type tagModel struct {
+	bun.BaseModel `bun:"table:tags,alias:tags"`
+
+	Name      string    `bun:"name,pk"`
+	CreatedAt time.Time `bun:"created_at"`
+}
+
+type titsTagModel struct {
+	bun.BaseModel `bun:"table:tits_tags,alias:tits_tags"`
+
+	TitsID string `bun:"tits_id,pk"`
+	Tag    string `bun:"tag,pk"`
+}
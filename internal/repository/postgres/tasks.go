@@ -12,35 +12,50 @@ type tasksModel struct {
 
 	ID              string                 `bun:"id,pk"`
 	CreatedAt       time.Time              `bun:"created_at"`
-	Processed       bool                   `bun:"processed"`
+	State           string                 `bun:"state"`
+	Attempts        int                    `bun:"attempts"`
+	NextAttemptAt   *time.Time             `bun:"next_attempt_at"`
 	Url             string                 `bun:"url"`
-	Status          string                 `bun:"status"`
-	NeedRetry       bool                   `bun:"need_retry"`
+	Source          string                 `bun:"source"`
+	Category        string                 `bun:"category"`
+	Metadata        map[string]string      `bun:"metadata,type:jsonb"`
 	Error           string                 `bun:"error"`
 	DetectionResult domain.DetectionResult `bun:"detection_result,type:jsonb"`
+	ClaimedBy       *string                `bun:"claimed_by"`
+	LeaseExpiresAt  *time.Time             `bun:"lease_expires_at"`
 }
 
 func (t *tasksModel) FromDomain(task domain.Task) {
 	t.ID = task.ID
-	t.Processed = task.Processed
 	t.CreatedAt = task.CreatedAt
+	t.State = string(task.State)
+	t.Attempts = task.Attempts
+	t.NextAttemptAt = task.NextAttemptAt
 	t.Url = task.Url
-	t.Status = task.Status
-	t.NeedRetry = task.NeedRetry
+	t.Source = task.Source
+	t.Category = task.Category
+	t.Metadata = task.Metadata
 	t.Error = task.Error
 	t.DetectionResult = task.DetectionResult
+	t.ClaimedBy = task.ClaimedBy
+	t.LeaseExpiresAt = task.LeaseExpiresAt
 }
 
 func TaskModelToDomain(model tasksModel) domain.Task {
 	return domain.Task{
 		ID:              model.ID,
 		CreatedAt:       model.CreatedAt,
-		Processed:       model.Processed,
+		State:           domain.TaskState(model.State),
+		Attempts:        model.Attempts,
+		NextAttemptAt:   model.NextAttemptAt,
 		Url:             model.Url,
-		Status:          model.Status,
-		NeedRetry:       model.NeedRetry,
+		Source:          model.Source,
+		Category:        model.Category,
+		Metadata:        model.Metadata,
 		Error:           model.Error,
 		DetectionResult: model.DetectionResult,
+		ClaimedBy:       model.ClaimedBy,
+		LeaseExpiresAt:  model.LeaseExpiresAt,
 	}
 }
 
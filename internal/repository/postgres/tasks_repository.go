@@ -2,11 +2,18 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"time"
 
 	"github.com/boobsrate/core/internal/domain"
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/driver/pgdriver"
 )
 
+// tasksCreatedChannel is notified by a trigger whenever tasks are inserted.
+const tasksCreatedChannel = "tasks_created"
+
 type TasksRepository struct {
 	db *bun.DB
 }
@@ -17,18 +24,96 @@ func NewTasksRepository(db *bun.DB) *TasksRepository {
 	}
 }
 
-func (r *TasksRepository) GetTask(ctx context.Context) (domain.Task, error) {
+// ClaimTask leases the oldest unfinished task that is due to the worker. Tasks whose lease has expired
+// are claimable again, so a crashed worker doesn't hold its task forever.
+// It returns domain.ErrNoTasks if there is nothing to claim.
+func (r *TasksRepository) ClaimTask(ctx context.Context, workerID string, lease time.Duration) (domain.Task, error) {
+	claimable := r.db.NewSelect().
+		Model((*tasksModel)(nil)).
+		Column("id").
+		Where("state NOT IN (?)", bun.In(domain.TaskFinalStates)).
+		Where("next_attempt_at IS NULL OR next_attempt_at <= now()").
+		Where("lease_expires_at IS NULL OR lease_expires_at < now()").
+		OrderExpr("next_attempt_at NULLS FIRST, created_at").
+		Limit(1).
+		For("UPDATE SKIP LOCKED")
+
 	var task tasksModel
-	err := r.db.NewSelect().Model(&task).Where("processed = False").OrderExpr("random()").Limit(1).Scan(ctx)
+	res, err := r.db.NewUpdate().
+		Model((*tasksModel)(nil)).
+		Set("claimed_by = ?", workerID).
+		Set("lease_expires_at = now() + ? * INTERVAL '1 millisecond'", lease.Milliseconds()).
+		Set("attempts = attempts + 1").
+		Where("id = (?)", claimable).
+		Returning("*").
+		Exec(ctx, &task)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Task{}, domain.ErrNoTasks
+	}
 	if err != nil {
 		return domain.Task{}, err
 	}
+	if err := checkAffected(res); err != nil {
+		return domain.Task{}, domain.ErrNoTasks
+	}
 	return TaskModelToDomain(task), nil
 }
 
+// ExtendLease prolongs the lease of a task held by the worker.
+// It returns domain.ErrTaskLeaseLost if the task was reclaimed by someone else.
+func (r *TasksRepository) ExtendLease(ctx context.Context, taskID, workerID string, lease time.Duration) error {
+	res, err := r.db.NewUpdate().
+		Model((*tasksModel)(nil)).
+		Set("lease_expires_at = now() + ? * INTERVAL '1 millisecond'", lease.Milliseconds()).
+		Where("id = ?", taskID).
+		Where("claimed_by = ?", workerID).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(res); err != nil {
+		return domain.ErrTaskLeaseLost
+	}
+	return nil
+}
+
+// GetDetectionBackfill returns the tasks after afterID whose cards have no detections stored yet.
+// Cards are created under the ID of their task.
+func (r *TasksRepository) GetDetectionBackfill(ctx context.Context, afterID string, limit int) ([]domain.Task, error) {
+	models := make([]tasksModel, 0, limit)
+	err := r.db.NewSelect().
+		Model(&models).
+		Join("JOIN tits ON tits.id = tasks.id").
+		Where("tasks.id > ?", afterID).
+		Where("COALESCE(tits.detections, 'null'::jsonb) = 'null'::jsonb").
+		Order("tasks.id ASC").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return tasksModelsToDomain(models), nil
+}
+
+// GetDetectedTasks returns the tasks after afterID that were accepted or rejected based on their detector results.
+func (r *TasksRepository) GetDetectedTasks(ctx context.Context, afterID string, limit int) ([]domain.Task, error) {
+	models := make([]tasksModel, 0, limit)
+	err := r.db.NewSelect().
+		Model(&models).
+		Where("id > ?", afterID).
+		Where("state IN (?)", bun.In([]domain.TaskState{domain.TaskStateDone, domain.TaskStateRejectedByDetector})).
+		Order("id ASC").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return tasksModelsToDomain(models), nil
+}
+
 func (r *TasksRepository) GetCountUnprocessedTasks(ctx context.Context) (int, error) {
 	var count int
-	count, err := r.db.NewSelect().Model((*tasksModel)(nil)).Where("processed = False").Count(ctx)
+	count, err := r.db.NewSelect().Model((*tasksModel)(nil)).Where("state NOT IN (?)", bun.In(domain.TaskFinalStates)).Count(ctx)
 	if err != nil {
 		return 0, err
 	}
@@ -45,9 +130,71 @@ func (r *TasksRepository) CreateTask(ctx context.Context, task []domain.Task) er
 	return err
 }
 
+// SetTaskState moves a task held by the worker to the given state without releasing the lease.
+func (r *TasksRepository) SetTaskState(ctx context.Context, taskID, workerID string, state domain.TaskState) error {
+	res, err := r.db.NewUpdate().
+		Model((*tasksModel)(nil)).
+		Set("state = ?", state).
+		Where("id = ?", taskID).
+		Where("claimed_by = ?", workerID).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(res); err != nil {
+		return domain.ErrTaskLeaseLost
+	}
+	return nil
+}
+
+// UpdateTask stores the task and releases its lease.
+// It returns domain.ErrTaskLeaseLost if the task was claimed and the lease was taken over by another worker.
 func (r *TasksRepository) UpdateTask(ctx context.Context, task domain.Task) error {
 	model := tasksModel{}
 	model.FromDomain(task)
-	_, err := r.db.NewUpdate().Model(&model).WherePK().Exec(ctx)
-	return err
+	model.ClaimedBy = nil
+	model.LeaseExpiresAt = nil
+
+	query := r.db.NewUpdate().Model(&model).WherePK()
+	if task.ClaimedBy != nil {
+		query = query.Where("claimed_by = ?", *task.ClaimedBy)
+	}
+	res, err := query.Exec(ctx)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(res); err != nil {
+		return domain.ErrTaskLeaseLost
+	}
+	return nil
+}
+
+// ListenTasks signals on the returned channel whenever new tasks are inserted.
+// The channel is closed once ctx is done.
+func (r *TasksRepository) ListenTasks(ctx context.Context) (<-chan struct{}, error) {
+	ln := pgdriver.NewListener(r.db)
+	if err := ln.Listen(ctx, tasksCreatedChannel); err != nil {
+		_ = ln.Close()
+		return nil, err
+	}
+
+	created := make(chan struct{}, 1)
+	go func() {
+		defer close(created)
+		defer ln.Close() // nolint: errcheck
+
+		notifications := ln.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-notifications:
+				select {
+				case created <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return created, nil
 }
@@ -10,25 +10,99 @@ import (
 type titsModel struct {
 	bun.BaseModel `bun:"table:tits,alias:tits,select:tits"`
 
-	ID        string    `bun:"id,pk"`
-	CreatedAt time.Time `bun:"created_at"`
-	Rating    int64     `bun:"rating"`
-	Abyss     bool      `bun:"abyss"`
+	ID          string             `bun:"id,pk"`
+	CreatedAt   time.Time          `bun:"created_at"`
+	Rating      int64              `bun:"rating"`
+	Score       float64            `bun:"score"`
+	Deviation   float64            `bun:"deviation"`
+	Volatility  float64            `bun:"volatility"`
+	Abyss       bool               `bun:"abyss"`
+	Impressions int64              `bun:"impressions"`
+	Detections  []domain.Detection `bun:"detections,type:jsonb"`
+	DHash       *int64             `bun:"dhash"`
+	DuplicateOf *string            `bun:"duplicate_of"`
+	Renditions  renditionsModel    `bun:"renditions,type:jsonb"`
+}
+
+// renditionsModel keeps the storage keys of the renditions, which the domain doesn't serialize.
+type renditionsModel map[string]renditionModel
+
+type renditionModel struct {
+	Key    string `json:"key"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Format string `json:"format"`
+}
+
+func renditionsFromDomain(renditions map[string]domain.ImageRendition) renditionsModel {
+	if renditions == nil {
+		return nil
+	}
+	models := make(renditionsModel, len(renditions))
+	for name, rendition := range renditions {
+		models[name] = renditionModel{
+			Key:    rendition.Key,
+			Width:  rendition.Width,
+			Height: rendition.Height,
+			Format: rendition.Format,
+		}
+	}
+	return models
+}
+
+func (m renditionsModel) toDomain() map[string]domain.ImageRendition {
+	if m == nil {
+		return nil
+	}
+	renditions := make(map[string]domain.ImageRendition, len(m))
+	for name, model := range m {
+		renditions[name] = domain.ImageRendition{
+			Key:    model.Key,
+			Width:  model.Width,
+			Height: model.Height,
+			Format: model.Format,
+		}
+	}
+	return renditions
 }
 
 func (t *titsModel) FromDomain(tits domain.Tits) {
 	t.CreatedAt = tits.CreatedAt
 	t.Rating = tits.Rating
+	t.Score = tits.Score
+	t.Deviation = tits.Deviation
+	t.Volatility = tits.Volatility
 	t.ID = tits.ID
 	t.Abyss = tits.Abyss
+	t.Impressions = tits.Impressions
+	t.Detections = tits.Detections
+	if tits.DHash != nil {
+		dhash := int64(*tits.DHash)
+		t.DHash = &dhash
+	}
+	t.DuplicateOf = tits.DuplicateOf
+	t.Renditions = renditionsFromDomain(tits.Renditions)
 }
 
 func titsModelToDomain(model titsModel) domain.Tits {
+	var dhash *uint64
+	if model.DHash != nil {
+		value := uint64(*model.DHash)
+		dhash = &value
+	}
 	return domain.Tits{
-		ID:        model.ID,
-		CreatedAt: model.CreatedAt,
-		Rating:    model.Rating,
-		Abyss:     model.Abyss,
+		ID:          model.ID,
+		CreatedAt:   model.CreatedAt,
+		Rating:      model.Rating,
+		Score:       model.Score,
+		Deviation:   model.Deviation,
+		Volatility:  model.Volatility,
+		Abyss:       model.Abyss,
+		Impressions: model.Impressions,
+		Detections:  model.Detections,
+		DHash:       dhash,
+		DuplicateOf: model.DuplicateOf,
+		Renditions:  model.Renditions.toDomain(),
 	}
 }
 
@@ -39,3 +113,9 @@ func titsModelsToDomain(models []titsModel) []domain.Tits {
 	}
 	return tits
 }
+
+// impressionsModel is a row of the impressions added to a card in one batch.
+type impressionsModel struct {
+	ID    string `bun:"id"`
+	Count int64  `bun:"count"`
+}
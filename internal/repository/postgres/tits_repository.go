@@ -3,12 +3,28 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/boobsrate/core/internal/domain"
+	"github.com/boobsrate/core/pkg/glicko"
 	"github.com/uptrace/bun"
 )
 
+// dhashDistanceExpr is the Hamming distance between the dhash of a card and the hash passed as the argument.
+const dhashDistanceExpr = "length(replace(((dhash # ?)::bit(64))::text, '0', ''))"
+
+// conservativeScoreExpr ranks cards by the lower bound of their rating so that
+// cards with few duels don't jump to the top on a lucky streak.
+const conservativeScoreExpr = "score - 2 * deviation"
+
+const (
+	votesDuelIDConstraint   = "votes_duel_id_uniq"
+	votesUserPairConstraint = "votes_user_pair_uniq"
+	reportsUserConstraint   = "reports_user_tits_uniq"
+	abyssVotesConstraint    = "abyss_votes_pkey"
+)
+
 type TitsRepository struct {
 	db *bun.DB
 }
@@ -19,26 +35,31 @@ func NewTitsRepository(db *bun.DB) *TitsRepository {
 	}
 }
 
-func (t *TitsRepository) GetTop(ctx context.Context, limit int, abyss bool) ([]domain.Tits, error) {
+func (t *TitsRepository) GetTop(ctx context.Context, limit int, abyss bool, tag string) ([]domain.Tits, error) {
 	titsModels := make([]titsModel, 0, limit)
 	err := t.db.NewSelect().
 		Model(&titsModels).
 		Where("COALESCE(abyss, FALSE) = ?", abyss).
-		OrderExpr("rating DESC").
+		Where("duplicate_of IS NULL").
+		Apply(t.withTag(tag)).
+		OrderExpr(conservativeScoreExpr + " DESC").
 		Limit(limit).
 		Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
 	tits := titsModelsToDomain(titsModels)
-	return tits, nil
+	return tits, t.attachTags(ctx, tits)
 }
 
-func (t *TitsRepository) GetTits(ctx context.Context) ([]domain.Tits, error) {
+// GetTits picks a random pair of cards. A non-empty tag limits the pair to the cards with that tag.
+func (t *TitsRepository) GetTits(ctx context.Context, tag string) ([]domain.Tits, error) {
 	titsModels := make([]titsModel, 0, 2)
 	err := t.db.NewSelect().
 		Model(&titsModels).
 		Where("COALESCE(abyss, FALSE) = ?", false).
+		Where("duplicate_of IS NULL").
+		Apply(t.withTag(tag)).
 		OrderExpr("random()").
 		Limit(2).
 		Scan(ctx)
@@ -46,48 +67,221 @@ func (t *TitsRepository) GetTits(ctx context.Context) ([]domain.Tits, error) {
 		return nil, err
 	}
 	tits := titsModelsToDomain(titsModels)
-	return tits, nil
+	return tits, t.attachTags(ctx, tits)
+}
+
+// AddImpressions adds the counted impressions to the cards in one statement.
+func (t *TitsRepository) AddImpressions(ctx context.Context, impressions map[string]int64) error {
+	if len(impressions) == 0 {
+		return nil
+	}
+	models := make([]impressionsModel, 0, len(impressions))
+	for titsID, count := range impressions {
+		models = append(models, impressionsModel{ID: titsID, Count: count})
+	}
+	_, err := t.db.NewUpdate().
+		With("pending", t.db.NewValues(&models)).
+		Model((*titsModel)(nil)).
+		TableExpr("pending").
+		Set("impressions = tits.impressions + pending.count").
+		Where("tits.id = pending.id").
+		Exec(ctx)
+	return err
+}
+
+func (t *TitsRepository) GetTitsByID(ctx context.Context, titsID string) (domain.Tits, error) {
+	var model titsModel
+	err := conn(ctx, t.db).NewSelect().
+		Model(&model).
+		Where("id = ?", titsID).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Tits{}, domain.ErrNotFound
+	}
+	if err != nil {
+		return domain.Tits{}, err
+	}
+	return titsModelToDomain(model), nil
+}
+
+// withTag limits a cards query to the cards with the tag, an empty tag matches every card.
+func (t *TitsRepository) withTag(tag string) func(*bun.SelectQuery) *bun.SelectQuery {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		if tag == "" {
+			return q
+		}
+		tagged := t.db.NewSelect().
+			Model((*titsTagModel)(nil)).
+			Column("tits_id").
+			Where("tag = ?", tag)
+		return q.Where("tits.id IN (?)", tagged)
+	}
+}
+
+// attachTags loads the tags of the cards in place.
+func (t *TitsRepository) attachTags(ctx context.Context, tits []domain.Tits) error {
+	if len(tits) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(tits))
+	for _, card := range tits {
+		ids = append(ids, card.ID)
+	}
+
+	var tagModels []titsTagModel
+	err := t.db.NewSelect().
+		Model(&tagModels).
+		Where("tits_id IN (?)", bun.In(ids)).
+		Order("tag ASC").
+		Scan(ctx)
+	if err != nil {
+		return err
+	}
+
+	tags := make(map[string][]string, len(tits))
+	for _, model := range tagModels {
+		tags[model.TitsID] = append(tags[model.TitsID], model.Tag)
+	}
+	for idx := range tits {
+		tits[idx].Tags = tags[tits[idx].ID]
+	}
+	return nil
 }
 
 func (t *TitsRepository) CreateTits(ctx context.Context, tits domain.Tits) error {
 	model := titsModel{}
 	model.FromDomain(tits)
-	_, err := t.db.NewInsert().
-		Model(&model).
+	return t.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewInsert().
+			Model(&model).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		return insertTags(ctx, tx, tits.ID, tits.Tags, tits.CreatedAt)
+	})
+}
+
+// SetDetections stores the detector results of the card and adds the tags derived from them.
+func (t *TitsRepository) SetDetections(ctx context.Context, titsID string, detections []domain.Detection, tags []string) error {
+	return t.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		if detections == nil {
+			detections = []domain.Detection{}
+		}
+		res, err := tx.NewUpdate().
+			Model(&titsModel{ID: titsID, Detections: detections}).
+			Column("detections").
+			WherePK().
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if err := checkAffected(res); err != nil {
+			return err
+		}
+		return insertTags(ctx, tx, titsID, tags, time.Now())
+	})
+}
+
+func insertTags(ctx context.Context, db bun.IDB, titsID string, tags []string, createdAt time.Time) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	tagModels := make([]tagModel, 0, len(tags))
+	titsTagModels := make([]titsTagModel, 0, len(tags))
+	for _, tag := range tags {
+		tagModels = append(tagModels, tagModel{Name: tag, CreatedAt: createdAt})
+		titsTagModels = append(titsTagModels, titsTagModel{TitsID: titsID, Tag: tag})
+	}
+
+	_, err := db.NewInsert().
+		Model(&tagModels).
+		On("CONFLICT (name) DO NOTHING").
 		Exec(ctx)
 	if err != nil {
 		return err
 	}
-	return nil
+	_, err = db.NewInsert().
+		Model(&titsTagModels).
+		On("CONFLICT DO NOTHING").
+		Exec(ctx)
+	return err
 }
 
-func (t *TitsRepository) IncreaseRating(ctx context.Context, titsID string) (int64, error) {
-	var rating int64
+func (t *TitsRepository) IncreaseRating(ctx context.Context, vote domain.Vote) (domain.Tits, domain.Tits, error) {
+	var winner, loser titsModel
 	err := t.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
-		_, err := tx.NewUpdate().
-			Model(&titsModel{}).
-			Set("rating = rating + 1").
-			Where("id = ?", titsID).
-			Returning("rating").
-			Exec(ctx, &rating)
+		var duel []titsModel
+		err := tx.NewSelect().
+			Model(&duel).
+			Where("id IN (?)", bun.In([]string{vote.TitsID, vote.OpponentID})).
+			Where("COALESCE(abyss, FALSE) = ?", false).
+			OrderExpr("id").
+			For("UPDATE").
+			Scan(ctx)
 		if err != nil {
 			return err
 		}
+		if len(duel) != 2 {
+			return domain.ErrNotFound
+		}
+
+		winner, loser = duel[0], duel[1]
+		if winner.ID != vote.TitsID {
+			winner, loser = loser, winner
+		}
+
+		winnerRating, loserRating := glicko.Duel(
+			glicko.Rating{Rating: winner.Score, Deviation: winner.Deviation, Volatility: winner.Volatility},
+			glicko.Rating{Rating: loser.Score, Deviation: loser.Deviation, Volatility: loser.Volatility},
+		)
+		winner.Rating++
+		winner.Score, winner.Deviation, winner.Volatility = winnerRating.Rating, winnerRating.Deviation, winnerRating.Volatility
+		loser.Score, loser.Deviation, loser.Volatility = loserRating.Rating, loserRating.Deviation, loserRating.Volatility
+
+		for _, model := range []*titsModel{&winner, &loser} {
+			_, err = tx.NewUpdate().
+				Model(model).
+				Column("rating", "score", "deviation", "volatility").
+				WherePK().
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		voteModel := voteModel{}
+		voteModel.FromDomain(vote)
+		voteModel.CreatedAt = time.Now().UTC()
 		_, err = tx.NewInsert().
-			Model(&voteModel{TitsID: titsID, CreatedAt: time.Now().UTC()}).
+			Model(&voteModel).
 			Exec(ctx)
+		switch {
+		case isUniqueViolation(err, votesDuelIDConstraint):
+			return domain.ErrDuelTokenUsed
+		case isUniqueViolation(err, votesUserPairConstraint):
+			return domain.ErrAlreadyVoted
+		}
 		return err
 	})
 	if err != nil {
-		return rating, err
+		return domain.Tits{}, domain.Tits{}, err
 	}
-	return rating, nil
+	return titsModelToDomain(winner), titsModelToDomain(loser), nil
 }
 
-func (t *TitsRepository) Report(ctx context.Context, titsID string) error {
+func (t *TitsRepository) Report(ctx context.Context, report domain.Report) error {
+	model := reportModel{}
+	model.FromDomain(report)
+	model.CreatedAt = time.Now().UTC()
 	_, err := t.db.NewInsert().
-		Model(&reportModel{TitsID: titsID, CreatedAt: time.Now().UTC()}).
+		Model(&model).
 		Exec(ctx)
+	if isUniqueViolation(err, reportsUserConstraint) {
+		return domain.ErrAlreadyReported
+	}
 	if err != nil {
 		return err
 	}
@@ -98,34 +292,510 @@ func (t *TitsRepository) GetReportsCount(ctx context.Context, titsID string) (in
 	count, err := t.db.NewSelect().
 		Model(&reportModel{}).
 		Where("tits_id = ?", titsID).
+		Where("status <> ?", domain.ReportStatusRejected).
+		Where("archived_at IS NULL").
 		Count(ctx)
 	return count, err
 }
 
-func (t *TitsRepository) GetTitsWithReportsThreshold(ctx context.Context, reportsThreshold int) ([]domain.Tits, error) {
-	var titsModels []titsModel
+// GetAbyssCandidates returns cards outside of the abyss with their active reports filed since the given time.
+func (t *TitsRepository) GetAbyssCandidates(ctx context.Context, since time.Time) ([]domain.AbyssCandidate, error) {
+	return t.abyssCandidates(ctx, false, since, "")
+}
+
+// GetAbyssCandidate returns the card in the abyss with all of its active reports.
+// It returns domain.ErrNotFound if the card isn't in the abyss.
+func (t *TitsRepository) GetAbyssCandidate(ctx context.Context, titsID string) (domain.AbyssCandidate, error) {
+	var model titsModel
 	err := t.db.NewSelect().
+		Model(&model).
+		Where("id = ?", titsID).
+		Where("abyss = TRUE").
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.AbyssCandidate{}, domain.ErrNotFound
+	}
+	if err != nil {
+		return domain.AbyssCandidate{}, err
+	}
+
+	candidates, err := t.abyssCandidates(ctx, true, time.Time{}, titsID)
+	if err != nil {
+		return domain.AbyssCandidate{}, err
+	}
+	candidate := domain.AbyssCandidate{Tits: titsModelToDomain(model)}
+	if len(candidates) > 0 {
+		candidate.Reports = candidates[0].Reports
+	}
+	return candidate, nil
+}
+
+// abyssCandidates returns the cards in or outside of the abyss with their active reports filed since the given time,
+// only the given card if titsID is set.
+func (t *TitsRepository) abyssCandidates(ctx context.Context, abyss bool, since time.Time, titsID string) ([]domain.AbyssCandidate, error) {
+	reporterStats := t.db.NewSelect().
+		Model((*reportModel)(nil)).
+		Column("user_id").
+		ColumnExpr("COUNT(*) FILTER (WHERE status = ?) AS accepted", domain.ReportStatusAccepted).
+		ColumnExpr("COUNT(*) FILTER (WHERE status = ?) AS rejected", domain.ReportStatusRejected).
+		Where("user_id IS NOT NULL").
+		Group("user_id")
+
+	query := t.db.NewSelect().
+		TableExpr("reports AS r").
+		ColumnExpr("r.tits_id, r.id AS report_id, r.reason, r.created_at").
+		ColumnExpr("COALESCE(r.user_id, '') AS reporter_id").
+		ColumnExpr("COALESCE(u.created_at, r.created_at) AS reporter_created_at").
+		ColumnExpr("COALESCE(stats.accepted, 0) AS reporter_accepted").
+		ColumnExpr("COALESCE(stats.rejected, 0) AS reporter_rejected").
+		Join("JOIN tits ON tits.id = r.tits_id AND COALESCE(tits.abyss, FALSE) = ?", abyss).
+		Join("LEFT JOIN users AS u ON u.id = r.user_id").
+		Join("LEFT JOIN (?) AS stats ON stats.user_id = r.user_id", reporterStats).
+		Where("r.status <> ?", domain.ReportStatusRejected).
+		Where("r.archived_at IS NULL").
+		Where("r.created_at >= ?", since).
+		OrderExpr("r.tits_id, r.created_at")
+	if titsID != "" {
+		query = query.Where("r.tits_id = ?", titsID)
+	}
+
+	var rows []abyssReportRow
+	err := query.Scan(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	reports := make(map[string][]domain.AbyssReport)
+	ids := make([]string, 0)
+	for _, row := range rows {
+		if _, ok := reports[row.TitsID]; !ok {
+			ids = append(ids, row.TitsID)
+		}
+		reports[row.TitsID] = append(reports[row.TitsID], abyssReportRowToDomain(row))
+	}
+
+	var titsModels []titsModel
+	err = t.db.NewSelect().
 		Model(&titsModels).
-		Where("COALESCE(abyss, FALSE) = ?", false).
-		Join("JOIN reports ON reports.tits_id = tits.id").
+		Where("id IN (?)", bun.In(ids)).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]domain.AbyssCandidate, 0, len(titsModels))
+	for _, model := range titsModels {
+		candidates = append(candidates, domain.AbyssCandidate{
+			Tits:    titsModelToDomain(model),
+			Reports: reports[model.ID],
+		})
+	}
+	return candidates, nil
+}
+
+// ApplyAbyssEvent moves the card to or from the abyss and stores the event in its history.
+// Restoring a card archives the reports that sent it there. Either way the votes
+// the card collected in the abyss are dropped.
+// It returns domain.ErrNotFound if the card doesn't exist or is already where the event would put it.
+func (t *TitsRepository) ApplyAbyssEvent(ctx context.Context, event domain.AbyssEvent) error {
+	if event.ID == "" {
+		event.ID = domain.NewID()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+
+	abyss := event.Action == domain.AbyssActionMove
+	return conn(ctx, t.db).RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		res, err := tx.NewUpdate().
+			Model((*titsModel)(nil)).
+			Set("abyss = ?", abyss).
+			Where("id = ?", event.TitsID).
+			Where("COALESCE(abyss, FALSE) = ?", !abyss).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if err := checkAffected(res); err != nil {
+			return err
+		}
+
+		if !abyss {
+			_, err = tx.NewUpdate().
+				Model((*reportModel)(nil)).
+				Set("archived_at = ?", event.CreatedAt).
+				Where("tits_id = ?", event.TitsID).
+				Where("archived_at IS NULL").
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.NewDelete().
+			Model((*abyssVoteModel)(nil)).
+			Where("tits_id = ?", event.TitsID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		model := abyssEventModel{}
+		model.FromDomain(event)
+		_, err = tx.NewInsert().
+			Model(&model).
+			Exec(ctx)
+		return err
+	})
+}
+
+func (t *TitsRepository) GetAbyssHistory(ctx context.Context, titsID string) ([]domain.AbyssEvent, error) {
+	var models []abyssEventModel
+	err := t.db.NewSelect().
+		Model(&models).
+		Where("tits_id = ?", titsID).
+		OrderExpr("created_at DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return abyssEventModelsToDomain(models), nil
+}
+
+func (t *TitsRepository) VoteAbyss(ctx context.Context, titsID, userID string) error {
+	model := abyssVoteModel{
+		TitsID:    titsID,
+		UserID:    userID,
+		CreatedAt: time.Now().UTC(),
+	}
+	_, err := t.db.NewInsert().
+		Model(&model).
+		Exec(ctx)
+	if isUniqueViolation(err, abyssVotesConstraint) {
+		return domain.ErrAlreadyVoted
+	}
+	return err
+}
+
+// GetAbyssRestoreCandidates returns cards in the abyss that collected at least minVotes votes there.
+func (t *TitsRepository) GetAbyssRestoreCandidates(ctx context.Context, minVotes int) ([]domain.AbyssRestoreCandidate, error) {
+	var rows []struct {
+		titsModel `bun:",extend"`
+		Votes     int `bun:"votes"`
+	}
+	err := t.db.NewSelect().
+		Model((*titsModel)(nil)).
+		ColumnExpr("tits.*").
+		ColumnExpr("COUNT(abyss_votes.user_id) AS votes").
+		Join("JOIN abyss_votes ON abyss_votes.tits_id = tits.id").
+		Where("COALESCE(tits.abyss, FALSE) = ?", true).
 		Group("tits.id").
-		Having("COUNT(tits.id) >= ?", reportsThreshold).
+		Having("COUNT(abyss_votes.user_id) >= ?", minVotes).
+		Scan(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]domain.AbyssRestoreCandidate, 0, len(rows))
+	for _, row := range rows {
+		candidates = append(candidates, domain.AbyssRestoreCandidate{
+			Tits:  titsModelToDomain(row.titsModel),
+			Votes: row.Votes,
+		})
+	}
+	return candidates, nil
+}
+
+func (t *TitsRepository) GetReportedTits(ctx context.Context, limit int) ([]domain.ReportedTits, error) {
+	var rows []struct {
+		titsModel      `bun:",extend"`
+		Reports        int       `bun:"reports"`
+		LastReportedAt time.Time `bun:"last_reported_at"`
+	}
+	err := t.db.NewSelect().
+		Model((*titsModel)(nil)).
+		ColumnExpr("tits.*").
+		ColumnExpr("COUNT(reports.tits_id) AS reports").
+		ColumnExpr("MAX(reports.created_at) AS last_reported_at").
+		Join("JOIN reports ON reports.tits_id = tits.id AND reports.status <> ?", domain.ReportStatusRejected).
+		Where("reports.archived_at IS NULL").
+		Group("tits.id").
+		OrderExpr("reports DESC, last_reported_at DESC").
+		Limit(limit).
+		Scan(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	reported := make([]domain.ReportedTits, 0, len(rows))
+	for _, row := range rows {
+		reported = append(reported, domain.ReportedTits{
+			Tits:           titsModelToDomain(row.titsModel),
+			Reports:        row.Reports,
+			LastReportedAt: row.LastReportedAt,
+		})
+	}
+	return reported, nil
+}
+
+func (t *TitsRepository) GetReports(ctx context.Context, status domain.ReportStatus, limit int) ([]domain.Report, error) {
+	models := make([]reportModel, 0, limit)
+	err := t.db.NewSelect().
+		Model(&models).
+		Where("status = ?", status).
+		OrderExpr("created_at ASC").
+		Limit(limit).
 		Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
-	tits := titsModelsToDomain(titsModels)
-	return tits, nil
+	return reportModelsToDomain(models), nil
 }
 
-func (t *TitsRepository) MoveToAbyss(ctx context.Context, titsID string) error {
-	_, err := t.db.NewUpdate().
+// DecideReport moves an open report to the given status.
+// It returns domain.ErrReportDecided if the report was already decided.
+func (t *TitsRepository) DecideReport(ctx context.Context, reportID string, status domain.ReportStatus, deciderID string) (domain.Report, error) {
+	var model reportModel
+	err := conn(ctx, t.db).RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		err := tx.NewSelect().
+			Model(&model).
+			Where("id = ?", reportID).
+			For("UPDATE").
+			Scan(ctx)
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if model.Status != string(domain.ReportStatusOpen) {
+			return domain.ErrReportDecided
+		}
+
+		decidedAt := time.Now().UTC()
+		model.Status = string(status)
+		model.DecidedBy = &deciderID
+		model.DecidedAt = &decidedAt
+		_, err = tx.NewUpdate().
+			Model(&model).
+			Column("status", "decided_by", "decided_at").
+			WherePK().
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return domain.Report{}, err
+	}
+	return reportModelToDomain(model), nil
+}
+
+func (t *TitsRepository) ResetRating(ctx context.Context, titsID string) error {
+	rating := glicko.NewRating()
+	res, err := conn(ctx, t.db).NewUpdate().
 		Model(&titsModel{}).
-		Set("abyss = ?", true).
+		Set("rating = 0").
+		Set("score = ?", rating.Rating).
+		Set("deviation = ?", rating.Deviation).
+		Set("volatility = ?", rating.Volatility).
 		Where("id = ?", titsID).
 		Exec(ctx)
 	if err != nil {
 		return err
 	}
-	return nil
+	return checkAffected(res)
+}
+
+// DeleteTits removes the card together with its votes and reports. If the card is the original of
+// near duplicates, the duplicate with most impressions, the oldest on a tie, becomes the original
+// of the others.
+func (t *TitsRepository) DeleteTits(ctx context.Context, titsID string) error {
+	return conn(ctx, t.db).RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		if err := promoteDuplicate(ctx, tx, titsID); err != nil {
+			return err
+		}
+
+		_, err := tx.NewDelete().
+			Model((*voteModel)(nil)).
+			Where("tits_id = ? OR opponent_id = ?", titsID, titsID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = tx.NewDelete().
+			Model((*reportModel)(nil)).
+			Where("tits_id = ?", titsID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		res, err := tx.NewDelete().
+			Model((*titsModel)(nil)).
+			Where("id = ?", titsID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		return checkAffected(res)
+	})
+}
+
+// promoteDuplicate makes one of the duplicates of the card the original of the rest.
+func promoteDuplicate(ctx context.Context, tx bun.Tx, originalID string) error {
+	var promotedID string
+	err := tx.NewSelect().
+		Model((*titsModel)(nil)).
+		Column("id").
+		Where("duplicate_of = ?", originalID).
+		OrderExpr("impressions DESC, created_at ASC").
+		Limit(1).
+		Scan(ctx, &promotedID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.NewUpdate().
+		Model((*titsModel)(nil)).
+		Set("duplicate_of = CASE WHEN id = ? THEN NULL ELSE ? END", promotedID, promotedID).
+		Where("duplicate_of = ?", originalID).
+		Exec(ctx)
+	return err
+}
+
+// FindSimilarTits returns the original card closest to the hash within maxDistance bits, domain.ErrNotFound if there is none.
+// The distance is computed for every hashed card, which is fine for the number of cards we have.
+func (t *TitsRepository) FindSimilarTits(ctx context.Context, dhash uint64, maxDistance int) (domain.Tits, error) {
+	var model titsModel
+	err := t.db.NewSelect().
+		Model(&model).
+		Where("dhash IS NOT NULL").
+		Where("duplicate_of IS NULL").
+		Where(dhashDistanceExpr+" <= ?", int64(dhash), maxDistance).
+		OrderExpr(dhashDistanceExpr+" ASC", int64(dhash)).
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Tits{}, domain.ErrNotFound
+	}
+	if err != nil {
+		return domain.Tits{}, err
+	}
+	return titsModelToDomain(model), nil
+}
+
+// GetOriginalTits returns all cards that aren't marked as duplicates.
+func (t *TitsRepository) GetOriginalTits(ctx context.Context) ([]domain.Tits, error) {
+	var models []titsModel
+	err := t.db.NewSelect().
+		Model(&models).
+		Where("duplicate_of IS NULL").
+		Order("created_at ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return titsModelsToDomain(models), nil
+}
+
+func (t *TitsRepository) SetDHash(ctx context.Context, titsID string, dhash uint64) error {
+	res, err := t.db.NewUpdate().
+		Model((*titsModel)(nil)).
+		Set("dhash = ?", int64(dhash)).
+		Where("id = ?", titsID).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return checkAffected(res)
+}
+
+// GetRenditionBackfill returns the cards after afterID ordered by ID, duplicates and cards in the abyss included.
+func (t *TitsRepository) GetRenditionBackfill(ctx context.Context, afterID string, limit int) ([]domain.Tits, error) {
+	models := make([]titsModel, 0, limit)
+	err := t.db.NewSelect().
+		Model(&models).
+		Column("id", "renditions").
+		Where("id > ?", afterID).
+		Order("id ASC").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return titsModelsToDomain(models), nil
+}
+
+func (t *TitsRepository) SetRenditions(ctx context.Context, titsID string, renditions map[string]domain.ImageRendition) error {
+	res, err := t.db.NewUpdate().
+		Model(&titsModel{ID: titsID, Renditions: renditionsFromDomain(renditions)}).
+		Column("renditions").
+		WherePK().
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return checkAffected(res)
+}
+
+// MergeDuplicate marks the card as a duplicate of the original and moves its votes over.
+// Votes between the two cards and votes the same user already cast for the original against
+// the same opponent are dropped. Ratings are left as they are.
+func (t *TitsRepository) MergeDuplicate(ctx context.Context, originalID, duplicateID string) error {
+	return t.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewDelete().
+			Model((*voteModel)(nil)).
+			Where("(tits_id = ? AND opponent_id = ?) OR (tits_id = ? AND opponent_id = ?)", duplicateID, originalID, originalID, duplicateID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewDelete().
+			TableExpr("votes AS v").
+			Where("v.user_id IS NOT NULL").
+			Where("v.tits_id = ? OR v.opponent_id = ?", duplicateID, duplicateID).
+			Where(`EXISTS (
+				SELECT 1 FROM votes AS k
+				WHERE k.user_id = v.user_id
+				  AND (k.tits_id = ? OR k.opponent_id = ?)
+				  AND (k.tits_id = CASE WHEN v.tits_id = ? THEN v.opponent_id ELSE v.tits_id END
+				    OR k.opponent_id = CASE WHEN v.tits_id = ? THEN v.opponent_id ELSE v.tits_id END)
+			)`, originalID, originalID, duplicateID, duplicateID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewUpdate().
+			Model((*voteModel)(nil)).
+			Set("tits_id = ?", originalID).
+			Where("tits_id = ?", duplicateID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = tx.NewUpdate().
+			Model((*voteModel)(nil)).
+			Set("opponent_id = ?", originalID).
+			Where("opponent_id = ?", duplicateID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.NewUpdate().
+			Model((*titsModel)(nil)).
+			Set("duplicate_of = ?", originalID).
+			Where("id = ? OR duplicate_of = ?", duplicateID, duplicateID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		return checkAffected(res)
+	})
 }
@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/uptrace/bun"
+)
+
+type txKey struct{}
+
+// Transactor runs functions in a database transaction. Repository methods that are called with
+// the context passed to the function and look up their connection with conn take part in it.
+type Transactor struct {
+	db *bun.DB
+}
+
+func NewTransactor(db *bun.DB) *Transactor {
+	return &Transactor{
+		db: db,
+	}
+}
+
+// RunInTx commits if fn returns nil and rolls back otherwise. Inside another transaction
+// it runs in a savepoint of that transaction.
+func (t *Transactor) RunInTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return conn(ctx, t.db).RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// conn returns the transaction started by Transactor.RunInTx for the context, or db outside of one.
+func conn(ctx context.Context, db *bun.DB) bun.IDB {
+	if tx, ok := ctx.Value(txKey{}).(bun.Tx); ok {
+		return tx
+	}
+	return db
+}
@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/boobsrate/core/internal/domain"
+	"github.com/uptrace/bun"
+)
+
+type userModel struct {
+	bun.BaseModel `bun:"table:users,alias:users,select:users"`
+
+	ID           string    `bun:"id,pk"`
+	TelegramID   int64     `bun:"telegram_id,nullzero"`
+	Username     string    `bun:"username"`
+	DisplayName  string    `bun:"display_name"`
+	AvatarURL    string    `bun:"avatar_url"`
+	CreatedAt    time.Time `bun:"created_at"`
+	LastSeenAt   time.Time `bun:"last_seen_at"`
+	Role         string    `bun:"role"`
+	Banned       bool      `bun:"banned"`
+	ChatMessages int64     `bun:"chat_messages"`
+}
+
+func (u *userModel) FromDomain(user domain.User) {
+	u.ID = user.ID
+	u.TelegramID = user.TelegramID
+	u.Username = user.Username
+	u.DisplayName = user.DisplayName
+	u.AvatarURL = user.AvatarURL
+	u.CreatedAt = user.CreatedAt
+	u.LastSeenAt = user.LastSeenAt
+	u.Role = string(user.Role)
+	u.Banned = user.Banned
+}
+
+func userModelToDomain(model userModel) domain.User {
+	return domain.User{
+		ID:          model.ID,
+		TelegramID:  model.TelegramID,
+		Username:    model.Username,
+		DisplayName: model.DisplayName,
+		AvatarURL:   model.AvatarURL,
+		CreatedAt:   model.CreatedAt,
+		LastSeenAt:  model.LastSeenAt,
+		Role:        domain.UserRole(model.Role),
+		Banned:      model.Banned,
+	}
+}
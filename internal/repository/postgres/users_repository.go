@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/boobsrate/core/internal/domain"
+	"github.com/uptrace/bun"
+)
+
+type UsersRepository struct {
+	db *bun.DB
+}
+
+func NewUsersRepository(db *bun.DB) *UsersRepository {
+	return &UsersRepository{
+		db: db,
+	}
+}
+
+// UpsertTelegramUser creates or updates the user of the Telegram account. Votes and reports that
+// were recorded under the Telegram ID before users existed are moved to the user ID in the same
+// transaction, except the ones the user already repeated under the user ID.
+func (r *UsersRepository) UpsertTelegramUser(ctx context.Context, user domain.User) (domain.User, error) {
+	model := userModel{}
+	model.FromDomain(user)
+	err := r.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewInsert().
+			Model(&model).
+			On("CONFLICT (telegram_id) DO UPDATE").
+			Set("username = EXCLUDED.username").
+			Set("display_name = EXCLUDED.display_name").
+			Set("avatar_url = EXCLUDED.avatar_url").
+			Set("last_seen_at = EXCLUDED.last_seen_at").
+			Returning("*").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if model.TelegramID == 0 {
+			return nil
+		}
+		return adoptTelegramIdentity(ctx, tx, model.ID, model.TelegramID)
+	})
+	if err != nil {
+		return domain.User{}, err
+	}
+	return userModelToDomain(model), nil
+}
+
+// adoptTelegramIdentity rewrites votes and reports recorded under the Telegram ID to the user ID.
+func adoptTelegramIdentity(ctx context.Context, tx bun.Tx, userID string, telegramID int64) error {
+	legacyID := strconv.FormatInt(telegramID, 10)
+
+	_, err := tx.NewUpdate().
+		TableExpr("votes AS vote").
+		Set("user_id = ?", userID).
+		Where("user_id = ?", legacyID).
+		Where(`NOT EXISTS (SELECT 1 FROM votes AS other WHERE other.user_id = ?
+			AND other.opponent_id IS NOT NULL AND vote.opponent_id IS NOT NULL
+			AND LEAST(other.tits_id, other.opponent_id) = LEAST(vote.tits_id, vote.opponent_id)
+			AND GREATEST(other.tits_id, other.opponent_id) = GREATEST(vote.tits_id, vote.opponent_id))`, userID).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.NewUpdate().
+		TableExpr("reports AS report").
+		Set("user_id = ?", userID).
+		Where("user_id = ?", legacyID).
+		Where("NOT EXISTS (SELECT 1 FROM reports AS other WHERE other.user_id = ? AND other.tits_id = report.tits_id)", userID).
+		Exec(ctx)
+	return err
+}
+
+func (r *UsersRepository) GetUser(ctx context.Context, userID string) (domain.User, error) {
+	var model userModel
+	err := r.db.NewSelect().
+		Model(&model).
+		Where("id = ?", userID).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.User{}, domain.ErrNotFound
+	}
+	if err != nil {
+		return domain.User{}, err
+	}
+	return userModelToDomain(model), nil
+}
+
+func (r *UsersRepository) GetUserStats(ctx context.Context, userID string) (domain.UserStats, error) {
+	var stats domain.UserStats
+	err := r.db.NewSelect().
+		Model((*userModel)(nil)).
+		ColumnExpr("(SELECT COUNT(*) FROM votes WHERE votes.user_id = users.id) AS votes_cast").
+		ColumnExpr("(SELECT COUNT(*) FROM reports WHERE reports.user_id = users.id) AS reports_filed").
+		ColumnExpr("users.chat_messages AS chat_messages").
+		Where("users.id = ?", userID).
+		Scan(ctx, &stats.VotesCast, &stats.ReportsFiled, &stats.ChatMessages)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.UserStats{}, domain.ErrNotFound
+	}
+	if err != nil {
+		return domain.UserStats{}, err
+	}
+	return stats, nil
+}
+
+func (r *UsersRepository) IncrementChatMessages(ctx context.Context, userID string) error {
+	_, err := r.db.NewUpdate().
+		Model((*userModel)(nil)).
+		Set("chat_messages = chat_messages + 1").
+		Set("last_seen_at = ?", time.Now().UTC()).
+		Where("id = ?", userID).
+		Exec(ctx)
+	return err
+}
+
+func (r *UsersRepository) SetBanned(ctx context.Context, userID string, banned bool) error {
+	res, err := conn(ctx, r.db).NewUpdate().
+		Model((*userModel)(nil)).
+		Set("banned = ?", banned).
+		Where("id = ?", userID).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return checkAffected(res)
+}
@@ -10,18 +10,27 @@ import (
 type voteModel struct {
 	bun.BaseModel `bun:"table:votes"`
 
-	TitsID    string    `bun:"tits_id"`
-	CreatedAt time.Time `bun:"created_at"`
+	TitsID     string    `bun:"tits_id"`
+	OpponentID string    `bun:"opponent_id"`
+	DuelID     string    `bun:"duel_id,nullzero"`
+	CreatedAt  time.Time `bun:"created_at"`
+	UserID     *string   `bun:"user_id"`
 }
 
 func (v *voteModel) FromDomain(vote domain.Vote) {
 	v.CreatedAt = vote.CreatedAt
 	v.TitsID = vote.TitsID
+	v.OpponentID = vote.OpponentID
+	v.DuelID = vote.DuelID
+	v.UserID = vote.UserID
 }
 
 func voteModelToDomain(model voteModel) domain.Vote {
 	return domain.Vote{
-		TitsID:    model.TitsID,
-		CreatedAt: model.CreatedAt,
+		TitsID:     model.TitsID,
+		OpponentID: model.OpponentID,
+		DuelID:     model.DuelID,
+		CreatedAt:  model.CreatedAt,
+		UserID:     model.UserID,
 	}
 }
@@ -0,0 +1,16 @@
+package abyss
+
+import (
+	"context"
+
+	"github.com/boobsrate/core/internal/domain"
+)
+
+type TitsDatabase interface {
+	GetTitsByID(ctx context.Context, titsID string) (domain.Tits, error)
+	VoteAbyss(ctx context.Context, titsID, userID string) error
+}
+
+type AppealsDatabase interface {
+	CreateAppeal(ctx context.Context, appeal domain.Appeal) error
+}
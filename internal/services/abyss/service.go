@@ -0,0 +1,88 @@
+package abyss
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/boobsrate/core/internal/domain"
+	"go.uber.org/zap"
+)
+
+const maxAppealReasonLength = 1000
+
+type Service struct {
+	tits    TitsDatabase
+	appeals AppealsDatabase
+
+	log *zap.Logger
+}
+
+func NewService(tits TitsDatabase, appeals AppealsDatabase, log *zap.Logger) *Service {
+	return &Service{
+		tits:    tits,
+		appeals: appeals,
+		log:     log.Named("abyss_service"),
+	}
+}
+
+// OpenAppeal asks moderators to restore a card from the abyss.
+// Cards are scraped and have no owner, so only moderators can appeal.
+func (s *Service) OpenAppeal(ctx context.Context, principal domain.Principal, titsID, reason string) (domain.Appeal, error) {
+	reason = strings.TrimSpace(reason)
+	if len(reason) > maxAppealReasonLength {
+		return domain.Appeal{}, domain.ErrInvalidInput
+	}
+
+	if !principal.Role.Includes(domain.UserRoleModerator) {
+		return domain.Appeal{}, domain.ErrForbidden
+	}
+	if _, err := s.abyssTits(ctx, titsID); err != nil {
+		return domain.Appeal{}, err
+	}
+
+	appeal := domain.Appeal{
+		ID:        domain.NewID(),
+		TitsID:    titsID,
+		OpenedBy:  principal.UserID,
+		Reason:    reason,
+		Status:    domain.AppealStatusOpen,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.appeals.CreateAppeal(ctx, appeal); err != nil {
+		if !errors.Is(err, domain.ErrAppealExists) {
+			s.log.Error("create appeal in db", zap.Error(err))
+		}
+		return domain.Appeal{}, err
+	}
+	return appeal, nil
+}
+
+// Vote counts a vote for restoring a card from the abyss.
+func (s *Service) Vote(ctx context.Context, principal domain.Principal, titsID string) error {
+	if _, err := s.abyssTits(ctx, titsID); err != nil {
+		return err
+	}
+
+	err := s.tits.VoteAbyss(ctx, titsID, principal.UserID)
+	if err != nil && !errors.Is(err, domain.ErrAlreadyVoted) {
+		s.log.Error("vote abyss in db", zap.Error(err))
+	}
+	return err
+}
+
+// abyssTits returns the card if it is in the abyss and domain.ErrNotFound otherwise.
+func (s *Service) abyssTits(ctx context.Context, titsID string) (domain.Tits, error) {
+	tits, err := s.tits.GetTitsByID(ctx, titsID)
+	if err != nil {
+		if !errors.Is(err, domain.ErrNotFound) {
+			s.log.Error("get tits from db", zap.Error(err))
+		}
+		return domain.Tits{}, err
+	}
+	if !tits.Abyss {
+		return domain.Tits{}, domain.ErrNotFound
+	}
+	return tits, nil
+}
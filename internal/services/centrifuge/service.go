@@ -16,6 +16,9 @@ import (
 	"google.golang.org/grpc"
 )
 
+// realtimeTokenTTL is the lifetime of connection tokens issued by /auth/get-token.
+const realtimeTokenTTL = time.Hour
+
 type Service struct {
 	wsChannel  chan domain.WSMessage
 	cli        centrifugeApi.CentrifugoApiClient
@@ -261,3 +264,35 @@ func (t keyAuth) GetRequestMetadata(ctx context.Context, uri ...string) (map[str
 func (t keyAuth) RequireTransportSecurity() bool {
 	return false
 }
+
+// RevokeToken revokes connection and subscription tokens with the given jti.
+func (s *Service) RevokeToken(ctx context.Context, tokenID string) error {
+	resp, err := s.cli.RevokeToken(ctx, &centrifugeApi.RevokeTokenRequest{
+		Uid:      tokenID,
+		ExpireAt: time.Now().Add(realtimeTokenTTL).Unix(),
+	})
+	if err != nil {
+		return err
+	}
+	if resp.GetError() != nil {
+		return fmt.Errorf("revoke token: %s", resp.GetError().GetMessage())
+	}
+	return nil
+}
+
+// InvalidateUserTokens invalidates all tokens of the user issued before now.
+func (s *Service) InvalidateUserTokens(ctx context.Context, userID string) error {
+	now := time.Now()
+	resp, err := s.cli.InvalidateUserTokens(ctx, &centrifugeApi.InvalidateUserTokensRequest{
+		User:         userID,
+		IssuedBefore: now.Unix(),
+		ExpireAt:     now.Add(realtimeTokenTTL).Unix(),
+	})
+	if err != nil {
+		return err
+	}
+	if resp.GetError() != nil {
+		return fmt.Errorf("invalidate user tokens: %s", resp.GetError().GetMessage())
+	}
+	return nil
+}
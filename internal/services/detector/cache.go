@@ -0,0 +1,98 @@
+package detector
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/boobsrate/core/internal/domain"
+	"github.com/boobsrate/core/pkg/lru"
+	"go.uber.org/zap"
+)
+
+type cachedResult struct {
+	result    domain.DetectionResult
+	expiresAt time.Time
+}
+
+// Cached remembers the results of a backend by the SHA-256 of the image, first in memory
+// and then in the database, so that retries and duplicate images don't reach the detector.
+// Database entries are kept per backend identity, so switching backends doesn't serve
+// results of the previous one. Detection by URL is not cached. Cache failures are logged
+// and the backend is asked instead.
+type Cached struct {
+	backend  Backend
+	identity string
+	db       CacheDatabase
+	memory   *lru.Cache[string, cachedResult]
+	ttl      time.Duration
+
+	log *zap.Logger
+}
+
+// NewCached wraps the backend. The identity must change whenever the backend would give
+// different results for the same image, e.g. its name and merge mode.
+func NewCached(backend Backend, identity string, db CacheDatabase, size int, ttl time.Duration, log *zap.Logger) *Cached {
+	return &Cached{
+		backend:  backend,
+		identity: identity,
+		db:       db,
+		memory:   lru.New[string, cachedResult](size),
+		ttl:      ttl,
+		log:      log.Named("detection_cache"),
+	}
+}
+
+func (c *Cached) Detect(ctx context.Context, url string) (domain.DetectionResult, error) {
+	return c.backend.Detect(ctx, url)
+}
+
+func (c *Cached) DetectBytes(ctx context.Context, image []byte) (domain.DetectionResult, error) {
+	sum := sha256.Sum256(image)
+	key := hex.EncodeToString(sum[:])
+
+	if cached, ok := c.memory.Get(key); ok {
+		if time.Now().Before(cached.expiresAt) {
+			cacheLookups.WithLabelValues("memory_hit").Inc()
+			return cached.result, nil
+		}
+		c.memory.Remove(key)
+	}
+
+	result, err := c.db.GetDetection(ctx, c.identity, key)
+	switch {
+	case err == nil:
+		cacheLookups.WithLabelValues("db_hit").Inc()
+		c.remember(key, result)
+		return result, nil
+	case !errors.Is(err, domain.ErrNotFound):
+		c.log.Error("get detection from db", zap.String("backend", c.identity), zap.String("image_sha256", key), zap.Error(err))
+	}
+	cacheLookups.WithLabelValues("miss").Inc()
+
+	result, err = c.backend.DetectBytes(ctx, image)
+	if err != nil {
+		return domain.DetectionResult{}, err
+	}
+
+	if err := c.db.PutDetection(ctx, c.identity, key, result, c.ttl); err != nil {
+		c.log.Error("put detection to db", zap.String("backend", c.identity), zap.String("image_sha256", key), zap.Error(err))
+	}
+	c.remember(key, result)
+	return result, nil
+}
+
+func (c *Cached) WaitAvailable(ctx context.Context) error {
+	return c.backend.WaitAvailable(ctx)
+}
+
+// remember keeps the result in memory. The memory TTL starts when the result is seen, so an entry
+// loaded from the database may outlive the row by up to one TTL.
+func (c *Cached) remember(key string, result domain.DetectionResult) {
+	c.memory.Add(key, cachedResult{
+		result:    result,
+		expiresAt: time.Now().Add(c.ttl),
+	})
+}
@@ -0,0 +1,117 @@
+package detector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/boobsrate/core/internal/domain"
+)
+
+type MergeMode string
+
+const (
+	// MergeMax keeps the highest score any backend gave to a class.
+	MergeMax MergeMode = "max"
+	// MergeAvg averages the scores of a class over all backends, a backend that missed the class counts as 0.
+	MergeAvg MergeMode = "avg"
+)
+
+// Ensemble asks all of its backends and merges their results into one detection per class.
+// The box of a merged detection is the one of the highest scoring detection of the class.
+// The call fails if any of the backends fails, so that the result doesn't depend on which ones answered.
+type Ensemble struct {
+	backends []Backend
+	merge    MergeMode
+}
+
+func NewEnsemble(merge MergeMode, backends ...Backend) (*Ensemble, error) {
+	if merge != MergeMax && merge != MergeAvg {
+		return nil, fmt.Errorf("unknown merge mode %q", merge)
+	}
+	if len(backends) == 0 {
+		return nil, fmt.Errorf("ensemble needs at least one backend")
+	}
+	return &Ensemble{
+		backends: backends,
+		merge:    merge,
+	}, nil
+}
+
+func (e *Ensemble) Detect(ctx context.Context, url string) (domain.DetectionResult, error) {
+	return e.collect(ctx, func(backend Backend) (domain.DetectionResult, error) {
+		return backend.Detect(ctx, url)
+	})
+}
+
+func (e *Ensemble) DetectBytes(ctx context.Context, image []byte) (domain.DetectionResult, error) {
+	return e.collect(ctx, func(backend Backend) (domain.DetectionResult, error) {
+		return backend.DetectBytes(ctx, image)
+	})
+}
+
+func (e *Ensemble) WaitAvailable(ctx context.Context) error {
+	for _, backend := range e.backends {
+		if err := backend.WaitAvailable(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Ensemble) collect(ctx context.Context, detect func(backend Backend) (domain.DetectionResult, error)) (domain.DetectionResult, error) {
+	results := make([]domain.DetectionResult, len(e.backends))
+	errs := make([]error, len(e.backends))
+
+	wg := &sync.WaitGroup{}
+	for idx, backend := range e.backends {
+		wg.Add(1)
+		go func(idx int, backend Backend) {
+			defer wg.Done()
+			results[idx], errs[idx] = detect(backend)
+		}(idx, backend)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return domain.DetectionResult{}, err
+		}
+	}
+	return mergeResults(e.merge, results), nil
+}
+
+func mergeResults(merge MergeMode, results []domain.DetectionResult) domain.DetectionResult {
+	best := make(map[domain.DetectionClass]domain.Detection)
+	sums := make(map[domain.DetectionClass]float64)
+	for _, result := range results {
+		// Only the best detection of a class counts per backend.
+		top := make(map[domain.DetectionClass]domain.Detection)
+		for _, detection := range result.Detections {
+			if current, ok := top[detection.Class]; !ok || detection.Score > current.Score {
+				top[detection.Class] = detection
+			}
+		}
+		for class, detection := range top {
+			sums[class] += detection.Score
+			if current, ok := best[class]; !ok || detection.Score > current.Score {
+				best[class] = detection
+			}
+		}
+	}
+
+	merged := domain.DetectionResult{
+		Detections: make([]domain.Detection, 0, len(best)),
+	}
+	for class, detection := range best {
+		if merge == MergeAvg {
+			detection.Score = sums[class] / float64(len(results))
+		}
+		merged.Detections = append(merged.Detections, detection)
+	}
+	sort.Slice(merged.Detections, func(i, j int) bool {
+		return merged.Detections[i].Class < merged.Detections[j].Class
+	})
+	return merged
+}
@@ -0,0 +1,73 @@
+package detector
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/boobsrate/core/internal/domain"
+)
+
+// Fixtures are the canned answers of the fake backend.
+// Images are looked up by the hex SHA-256 of their bytes, URLs as is, and Default answers everything else.
+type Fixtures struct {
+	Default *domain.DetectionResult           `json:"default,omitempty"`
+	Images  map[string]domain.DetectionResult `json:"images,omitempty"`
+	URLs    map[string]domain.DetectionResult `json:"urls,omitempty"`
+}
+
+// Fake is a deterministic backend that answers from fixtures, so the parser can run without a detector.
+type Fake struct {
+	fixtures Fixtures
+}
+
+// NewFakeFromFile loads the fixtures of a fake backend from a JSON file.
+func NewFakeFromFile(path string) (*Fake, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var fixtures Fixtures
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&fixtures); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+	return NewFake(fixtures), nil
+}
+
+func NewFake(fixtures Fixtures) *Fake {
+	return &Fake{fixtures: fixtures}
+}
+
+func (f *Fake) Detect(ctx context.Context, url string) (domain.DetectionResult, error) {
+	if result, ok := f.fixtures.URLs[url]; ok {
+		return result, nil
+	}
+	return f.fallback(url)
+}
+
+func (f *Fake) DetectBytes(ctx context.Context, image []byte) (domain.DetectionResult, error) {
+	sum := sha256.Sum256(image)
+	key := hex.EncodeToString(sum[:])
+	if result, ok := f.fixtures.Images[key]; ok {
+		return result, nil
+	}
+	return f.fallback("sha256:" + key)
+}
+
+func (f *Fake) WaitAvailable(ctx context.Context) error {
+	return nil
+}
+
+func (f *Fake) fallback(key string) (domain.DetectionResult, error) {
+	if f.fixtures.Default == nil {
+		return domain.DetectionResult{}, fmt.Errorf("no fixture for %s", key)
+	}
+	return *f.fixtures.Default, nil
+}
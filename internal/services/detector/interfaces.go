@@ -0,0 +1,20 @@
+package detector
+
+import (
+	"context"
+	"time"
+
+	"github.com/boobsrate/core/internal/domain"
+)
+
+// Backend is a detector implementation, e.g. an HTTP client of a NudeNet-style API.
+type Backend interface {
+	Detect(ctx context.Context, url string) (domain.DetectionResult, error)
+	DetectBytes(ctx context.Context, image []byte) (domain.DetectionResult, error)
+	WaitAvailable(ctx context.Context) error
+}
+
+type CacheDatabase interface {
+	GetDetection(ctx context.Context, backend, imageSHA256 string) (domain.DetectionResult, error)
+	PutDetection(ctx context.Context, backend, imageSHA256 string, result domain.DetectionResult, ttl time.Duration) error
+}
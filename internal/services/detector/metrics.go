@@ -0,0 +1,12 @@
+package detector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var cacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "detector",
+	Name:      "cache_lookups_total",
+	Help:      "Number of detection cache lookups by result: memory_hit, db_hit or miss.",
+}, []string{"result"})
@@ -0,0 +1,60 @@
+package detector
+
+import (
+	"fmt"
+	"sort"
+)
+
+// BackendFactory creates a backend. Factories run only for the backends that are selected.
+type BackendFactory func(registry *Registry) (Backend, error)
+
+// Registry holds the detector backends that can be selected by name.
+type Registry struct {
+	factories map[string]BackendFactory
+	built     map[string]Backend
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		factories: make(map[string]BackendFactory),
+		built:     make(map[string]Backend),
+	}
+}
+
+func (r *Registry) Register(name string, factory BackendFactory) {
+	r.factories[name] = factory
+}
+
+// Get builds the backend registered under name. A backend is built once, so the ensemble
+// and the parser share the same client, its concurrency limit and circuit breaker.
+func (r *Registry) Get(name string) (Backend, error) {
+	if backend, ok := r.built[name]; ok {
+		if backend == nil {
+			return nil, fmt.Errorf("detector backend %q depends on itself", name)
+		}
+		return backend, nil
+	}
+
+	factory, ok := r.factories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown detector backend %q, registered: %v", name, r.names())
+	}
+	// Marked before building so that an ensemble listing itself fails instead of recursing.
+	r.built[name] = nil
+	backend, err := factory(r)
+	if err != nil {
+		delete(r.built, name)
+		return nil, fmt.Errorf("create detector backend %q: %w", name, err)
+	}
+	r.built[name] = backend
+	return backend, nil
+}
+
+func (r *Registry) names() []string {
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -3,23 +3,27 @@ package detector
 import (
 	"context"
 
-	"github.com/boobsrate/core/internal/clients/detection"
 	"github.com/boobsrate/core/internal/domain"
 	"go.uber.org/zap"
 )
 
 type Service struct {
 	log    *zap.Logger
-	client *detection.Client
+	client Backend
 }
 
-func NewService(log *zap.Logger, client *detection.Client) *Service {
+func NewService(log *zap.Logger, client Backend) *Service {
 	return &Service{
 		log:    log.Named("detector"),
 		client: client,
 	}
 }
 
+// WaitAvailable blocks while the detector is considered down.
+func (s *Service) WaitAvailable(ctx context.Context) error {
+	return s.client.WaitAvailable(ctx)
+}
+
 func (s *Service) Detect(ctx context.Context, url string) (domain.DetectionResult, error) {
 	s.log.Info("detecting content", zap.String("url", url))
 
@@ -31,3 +35,15 @@ func (s *Service) Detect(ctx context.Context, url string) (domain.DetectionResul
 
 	return result, nil
 }
+
+func (s *Service) DetectBytes(ctx context.Context, image []byte) (domain.DetectionResult, error) {
+	s.log.Info("detecting content", zap.Int("size", len(image)))
+
+	result, err := s.client.DetectBytes(ctx, image)
+	if err != nil {
+		s.log.Error("failed to detect content", zap.Error(err), zap.Int("size", len(image)))
+		return domain.DetectionResult{}, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,76 @@
+package duel
+
+import (
+	"time"
+
+	"github.com/boobsrate/core/internal/domain"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const duelAudience = "duel"
+
+type claims struct {
+	jwt.RegisteredClaims
+
+	Tits []string `json:"tits"`
+}
+
+type Service struct {
+	key []byte
+	ttl time.Duration
+}
+
+func NewService(signingKey string, ttl time.Duration) *Service {
+	return &Service{
+		key: []byte(signingKey),
+		ttl: ttl,
+	}
+}
+
+// Issue signs a duel token binding the served pair of cards to the viewer.
+func (s *Service) Issue(viewerID string, tits []domain.Tits) (string, error) {
+	if len(tits) != 2 {
+		return "", domain.ErrInvalidInput
+	}
+
+	now := time.Now()
+	duelClaims := claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        domain.NewID(),
+			Subject:   viewerID,
+			Audience:  jwt.ClaimStrings{duelAudience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+		Tits: []string{tits[0].ID, tits[1].ID},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, duelClaims)
+	return token.SignedString(s.key)
+}
+
+// Verify checks the signature, expiry and viewer of a duel token.
+// It does not check whether the token was already used, that's up to the votes storage.
+func (s *Service) Verify(token, viewerID string) (domain.Duel, error) {
+	var duelClaims claims
+	_, err := jwt.ParseWithClaims(token, &duelClaims, func(token *jwt.Token) (interface{}, error) {
+		return s.key, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return domain.Duel{}, domain.ErrDuelTokenInvalid
+	}
+
+	if !duelClaims.VerifyAudience(duelAudience, true) ||
+		duelClaims.ID == "" ||
+		duelClaims.Subject != viewerID ||
+		len(duelClaims.Tits) != 2 {
+		return domain.Duel{}, domain.ErrDuelTokenInvalid
+	}
+
+	return domain.Duel{
+		ID:        duelClaims.ID,
+		TitsIDs:   duelClaims.Tits,
+		ViewerID:  duelClaims.Subject,
+		ExpiresAt: duelClaims.ExpiresAt.Time,
+	}, nil
+}
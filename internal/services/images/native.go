@@ -0,0 +1,46 @@
+package images
+
+import (
+	"context"
+	"errors"
+
+	"github.com/boobsrate/core/internal/domain"
+	"github.com/boobsrate/core/pkg/imageproc"
+)
+
+const (
+	defaultQuality  = 85
+	jpegContentType = "image/jpeg"
+)
+
+// Native resizes images in-process and encodes them as JPEG.
+type Native struct {
+	quality int
+}
+
+func NewNative(quality int) *Native {
+	if quality < 1 || quality > 100 {
+		quality = defaultQuality
+	}
+	return &Native{quality: quality}
+}
+
+func (n *Native) Process(ctx context.Context, src domain.ImageSource, width int) (domain.ProcessedImage, error) {
+	if len(src.Data) == 0 {
+		return domain.ProcessedImage{}, errors.New("image data is empty")
+	}
+
+	img, err := imageproc.DecodeResized(src.Data, width)
+	if err != nil {
+		return domain.ProcessedImage{}, err
+	}
+	if err := ctx.Err(); err != nil {
+		return domain.ProcessedImage{}, err
+	}
+
+	data, err := imageproc.EncodeJPEG(img, n.quality)
+	if err != nil {
+		return domain.ProcessedImage{}, err
+	}
+	return domain.ProcessedImage{Data: data, ContentType: jpegContentType}, nil
+}
@@ -0,0 +1,37 @@
+package images
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/boobsrate/core/internal/clients/optimizer"
+	"github.com/boobsrate/core/internal/domain"
+)
+
+const (
+	ProcessorNative    = "native"
+	ProcessorOptimizer = "optimizer"
+)
+
+type Processor interface {
+	Process(ctx context.Context, src domain.ImageSource, width int) (domain.ProcessedImage, error)
+}
+
+type Config struct {
+	// Kind is either native or optimizer.
+	Kind               string
+	Quality            int
+	OptimizerEndpoint  string
+	OptimizerSourceURL string
+}
+
+// NewProcessor returns the image processor selected by the config.
+func NewProcessor(cfg Config) (Processor, error) {
+	switch cfg.Kind {
+	case ProcessorNative, "":
+		return NewNative(cfg.Quality), nil
+	case ProcessorOptimizer:
+		return optimizer.NewClient(cfg.OptimizerEndpoint, cfg.OptimizerSourceURL), nil
+	}
+	return nil, fmt.Errorf("unknown image processor %q", cfg.Kind)
+}
@@ -0,0 +1,51 @@
+package moderation
+
+import (
+	"context"
+
+	"github.com/boobsrate/core/internal/domain"
+)
+
+// Transactor runs fn in a database transaction. The database calls made with the context
+// passed to fn take part in it.
+type Transactor interface {
+	RunInTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type TitsDatabase interface {
+	GetReportedTits(ctx context.Context, limit int) ([]domain.ReportedTits, error)
+	GetReports(ctx context.Context, status domain.ReportStatus, limit int) ([]domain.Report, error)
+	DecideReport(ctx context.Context, reportID string, status domain.ReportStatus, deciderID string) (domain.Report, error)
+	ApplyAbyssEvent(ctx context.Context, event domain.AbyssEvent) error
+	GetAbyssHistory(ctx context.Context, titsID string) ([]domain.AbyssEvent, error)
+	ResetRating(ctx context.Context, titsID string) error
+	GetTitsByID(ctx context.Context, titsID string) (domain.Tits, error)
+	DeleteTits(ctx context.Context, titsID string) error
+}
+
+type AppealsDatabase interface {
+	GetAppeals(ctx context.Context, status domain.AppealStatus, limit int) ([]domain.Appeal, error)
+	DecideAppeal(ctx context.Context, appealID string, status domain.AppealStatus, deciderID string) (domain.Appeal, error)
+}
+
+type UsersDatabase interface {
+	SetBanned(ctx context.Context, userID string, banned bool) error
+}
+
+type AuditDatabase interface {
+	CreateAuditEntry(ctx context.Context, entry domain.AuditEntry) error
+	GetAuditLog(ctx context.Context, targetID string, limit int) ([]domain.AuditEntry, error)
+}
+
+type Storage interface {
+	DeleteImage(ctx context.Context, imageName string) error
+}
+
+type Sessions interface {
+	RevokeAll(ctx context.Context, userID string) error
+}
+
+// AbyssKeeper restores cards from the abyss that no longer meet the policy.
+type AbyssKeeper interface {
+	Reconsider(ctx context.Context, titsID, reportID string) error
+}
@@ -0,0 +1,299 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/boobsrate/core/internal/domain"
+	"go.uber.org/zap"
+)
+
+const (
+	abyssPolicyModerator = "moderator"
+	abyssPolicyAppeal    = "appeal"
+)
+
+type Service struct {
+	tx       Transactor
+	tits     TitsDatabase
+	appeals  AppealsDatabase
+	users    UsersDatabase
+	audit    AuditDatabase
+	storage  Storage
+	sessions Sessions
+	keeper   AbyssKeeper
+
+	log *zap.Logger
+}
+
+func NewService(tx Transactor, tits TitsDatabase, appeals AppealsDatabase, users UsersDatabase, audit AuditDatabase, storage Storage, sessions Sessions, keeper AbyssKeeper, log *zap.Logger) *Service {
+	return &Service{
+		tx:       tx,
+		tits:     tits,
+		appeals:  appeals,
+		users:    users,
+		audit:    audit,
+		storage:  storage,
+		sessions: sessions,
+		keeper:   keeper,
+		log:      log.Named("moderation_service"),
+	}
+}
+
+func (s *Service) GetReportedTits(ctx context.Context, limit int) ([]domain.ReportedTits, error) {
+	tits, err := s.tits.GetReportedTits(ctx, limit)
+	if err != nil {
+		s.log.Error("get reported tits from db", zap.Error(err))
+		return nil, err
+	}
+	return tits, nil
+}
+
+func (s *Service) GetReportQueue(ctx context.Context, status domain.ReportStatus, limit int) ([]domain.Report, error) {
+	if !status.Valid() {
+		return nil, domain.ErrInvalidInput
+	}
+	reports, err := s.tits.GetReports(ctx, status, limit)
+	if err != nil {
+		s.log.Error("get reports from db", zap.Error(err))
+		return nil, err
+	}
+	return reports, nil
+}
+
+// DecideReport accepts or rejects an open report. Rejected reports no longer count toward the abyss threshold,
+// so a card the report helped send to the abyss is reconsidered.
+func (s *Service) DecideReport(ctx context.Context, actor domain.Principal, reportID string, accept bool) (domain.Report, error) {
+	action, status := domain.AuditActionRejectReport, domain.ReportStatusRejected
+	if accept {
+		action, status = domain.AuditActionAcceptReport, domain.ReportStatusAccepted
+	}
+
+	var report domain.Report
+	err := s.act(ctx, actor, action, reportID, func(ctx context.Context) (map[string]interface{}, error) {
+		var err error
+		report, err = s.tits.DecideReport(ctx, reportID, status, actor.UserID)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"tits_id": report.TitsID, "reason": report.Reason}, nil
+	})
+	if err != nil {
+		return domain.Report{}, err
+	}
+
+	if !accept {
+		if err := s.keeper.Reconsider(ctx, report.TitsID, report.ID); err != nil {
+			s.log.Error("reconsider tits after rejected report", zap.String("tits_id", report.TitsID), zap.Error(err))
+		}
+	}
+	return report, nil
+}
+
+func (s *Service) GetAuditLog(ctx context.Context, targetID string, limit int) ([]domain.AuditEntry, error) {
+	entries, err := s.audit.GetAuditLog(ctx, targetID, limit)
+	if err != nil {
+		s.log.Error("get audit log from db", zap.Error(err))
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (s *Service) MoveToAbyss(ctx context.Context, actor domain.Principal, titsID string) error {
+	return s.act(ctx, actor, domain.AuditActionMoveToAbyss, titsID, func(ctx context.Context) (map[string]interface{}, error) {
+		return nil, s.tits.ApplyAbyssEvent(ctx, abyssEvent(actor, titsID, domain.AbyssActionMove, abyssPolicyModerator, nil))
+	})
+}
+
+func (s *Service) RestoreFromAbyss(ctx context.Context, actor domain.Principal, titsID string) error {
+	return s.act(ctx, actor, domain.AuditActionRestoreFromAbyss, titsID, func(ctx context.Context) (map[string]interface{}, error) {
+		return nil, s.tits.ApplyAbyssEvent(ctx, abyssEvent(actor, titsID, domain.AbyssActionRestore, abyssPolicyModerator, nil))
+	})
+}
+
+func (s *Service) GetAbyssHistory(ctx context.Context, titsID string) ([]domain.AbyssEvent, error) {
+	events, err := s.tits.GetAbyssHistory(ctx, titsID)
+	if err != nil {
+		s.log.Error("get abyss history from db", zap.Error(err))
+		return nil, err
+	}
+	return events, nil
+}
+
+func (s *Service) GetAppeals(ctx context.Context, status domain.AppealStatus, limit int) ([]domain.Appeal, error) {
+	if !status.Valid() {
+		return nil, domain.ErrInvalidInput
+	}
+	appeals, err := s.appeals.GetAppeals(ctx, status, limit)
+	if err != nil {
+		s.log.Error("get appeals from db", zap.Error(err))
+		return nil, err
+	}
+	return appeals, nil
+}
+
+// DecideAppeal grants or denies an open appeal, granting it restores the card from the abyss.
+// The decision and the restore are committed together, a failed restore leaves the appeal open.
+func (s *Service) DecideAppeal(ctx context.Context, actor domain.Principal, appealID string, grant bool) (domain.Appeal, error) {
+	action, status := domain.AuditActionDenyAppeal, domain.AppealStatusDenied
+	if grant {
+		action, status = domain.AuditActionGrantAppeal, domain.AppealStatusGranted
+	}
+
+	var appeal domain.Appeal
+	err := s.act(ctx, actor, action, appealID, func(ctx context.Context) (map[string]interface{}, error) {
+		var err error
+		appeal, err = s.appeals.DecideAppeal(ctx, appealID, status, actor.UserID)
+		if err != nil {
+			return nil, err
+		}
+		details := map[string]interface{}{"tits_id": appeal.TitsID}
+		if !grant {
+			return details, nil
+		}
+
+		inputs := map[string]interface{}{"appeal_id": appeal.ID}
+		err = s.tits.ApplyAbyssEvent(ctx, abyssEvent(actor, appeal.TitsID, domain.AbyssActionRestore, abyssPolicyAppeal, inputs))
+		if errors.Is(err, domain.ErrNotFound) {
+			// The card has already left the abyss in the meantime.
+			return details, nil
+		}
+		return details, err
+	})
+	if err != nil {
+		return domain.Appeal{}, err
+	}
+	return appeal, nil
+}
+
+// ResetRating puts the card back to the initial rating, the audit entry keeps the rating it had.
+func (s *Service) ResetRating(ctx context.Context, actor domain.Principal, titsID string) error {
+	return s.act(ctx, actor, domain.AuditActionResetRating, titsID, func(ctx context.Context) (map[string]interface{}, error) {
+		tits, err := s.tits.GetTitsByID(ctx, titsID)
+		if err != nil {
+			return nil, err
+		}
+		details := map[string]interface{}{
+			"rating":     tits.Rating,
+			"score":      tits.Score,
+			"deviation":  tits.Deviation,
+			"volatility": tits.Volatility,
+		}
+		return details, s.tits.ResetRating(ctx, titsID)
+	})
+}
+
+// DeleteTits removes the card from the database and its images, renditions included, from the storage.
+// The images are removed after the deletion is recorded, failing to remove them is only logged.
+func (s *Service) DeleteTits(ctx context.Context, actor domain.Principal, titsID string) error {
+	var tits domain.Tits
+	err := s.act(ctx, actor, domain.AuditActionDeleteTits, titsID, func(ctx context.Context) (map[string]interface{}, error) {
+		var err error
+		tits, err = s.tits.GetTitsByID(ctx, titsID)
+		if err != nil {
+			return nil, err
+		}
+		return nil, s.tits.DeleteTits(ctx, titsID)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, imageName := range imageKeys(tits) {
+		if err := s.storage.DeleteImage(ctx, imageName); err != nil {
+			s.log.Error("delete image from storage", zap.String("tits_id", titsID), zap.String("image", imageName), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// imageKeys lists the storage keys of the card: the legacy images and every stored rendition.
+func imageKeys(tits domain.Tits) []string {
+	keys := []string{fmt.Sprintf("%s.jpg", tits.ID), fmt.Sprintf("%s.webp", tits.ID)}
+	seen := map[string]bool{keys[0]: true, keys[1]: true}
+	for _, rendition := range tits.Renditions {
+		if rendition.Key == "" || seen[rendition.Key] {
+			continue
+		}
+		seen[rendition.Key] = true
+		keys = append(keys, rendition.Key)
+	}
+	return keys
+}
+
+// SetUserBanned bans or unbans the user, banning also ends all of the user's sessions.
+func (s *Service) SetUserBanned(ctx context.Context, actor domain.Principal, userID string, banned bool) error {
+	action := domain.AuditActionUnbanUser
+	if banned {
+		action = domain.AuditActionBanUser
+	}
+	err := s.act(ctx, actor, action, userID, func(ctx context.Context) (map[string]interface{}, error) {
+		if actor.UserID == userID {
+			return nil, domain.ErrInvalidInput
+		}
+		return nil, s.users.SetBanned(ctx, userID, banned)
+	})
+	if err != nil || !banned {
+		return err
+	}
+
+	if err := s.sessions.RevokeAll(ctx, userID); err != nil {
+		s.log.Error("revoke sessions of banned user", zap.String("user_id", userID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func abyssEvent(
+	actor domain.Principal,
+	titsID string,
+	action domain.AbyssAction,
+	policy string,
+	inputs map[string]interface{},
+) domain.AbyssEvent {
+	return domain.AbyssEvent{
+		TitsID:  titsID,
+		Action:  action,
+		Policy:  policy,
+		ActorID: &actor.UserID,
+		Inputs:  inputs,
+	}
+}
+
+// act runs the database mutation of a moderation action and records it in the audit log, both in
+// one transaction. do runs with the context of the transaction and returns the details of the
+// audit entry. Side effects outside the database run after act, so they only follow recorded actions.
+func (s *Service) act(
+	ctx context.Context,
+	actor domain.Principal,
+	action domain.AuditAction,
+	targetID string,
+	do func(ctx context.Context) (map[string]interface{}, error),
+) error {
+	err := s.tx.RunInTx(ctx, func(ctx context.Context) error {
+		details, err := do(ctx)
+		if err != nil {
+			return err
+		}
+		return s.audit.CreateAuditEntry(ctx, domain.AuditEntry{
+			ID:        domain.NewID(),
+			ActorID:   actor.UserID,
+			Action:    action,
+			TargetID:  targetID,
+			Details:   details,
+			CreatedAt: time.Now().UTC(),
+		})
+	})
+	if err != nil {
+		s.log.Error("moderation action",
+			zap.String("action", string(action)),
+			zap.String("target_id", targetID),
+			zap.String("actor_id", actor.UserID),
+			zap.Error(err),
+		)
+		return err
+	}
+	return nil
+}
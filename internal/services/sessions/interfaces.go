@@ -0,0 +1,23 @@
+package sessions
+
+import (
+	"context"
+	"time"
+
+	"github.com/boobsrate/core/internal/domain"
+)
+
+type Database interface {
+	CreateSession(ctx context.Context, session domain.Session) error
+	GetSession(ctx context.Context, sessionID string) (domain.Session, error)
+	GetSessionByRefreshHash(ctx context.Context, refreshHash string) (domain.Session, error)
+	RotateRefreshToken(ctx context.Context, sessionID, oldHash, newHash string, expiresAt time.Time) error
+	RevokeSession(ctx context.Context, sessionID string) error
+	RevokeUserSessions(ctx context.Context, userID string) ([]domain.Session, error)
+}
+
+// Realtime drops realtime connections that were authorized by revoked sessions.
+type Realtime interface {
+	RevokeToken(ctx context.Context, tokenID string) error
+	InvalidateUserTokens(ctx context.Context, userID string) error
+}
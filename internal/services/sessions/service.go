@@ -0,0 +1,197 @@
+package sessions
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/boobsrate/core/internal/config"
+	"github.com/boobsrate/core/internal/domain"
+	"github.com/golang-jwt/jwt/v4"
+	"go.uber.org/zap"
+)
+
+const refreshTokenBytes = 32
+
+type Service struct {
+	db       Database
+	realtime Realtime
+
+	key        []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+
+	log *zap.Logger
+}
+
+func NewService(db Database, realtime Realtime, signingKey string, cfg config.SessionConfig, log *zap.Logger) *Service {
+	return &Service{
+		db:         db,
+		realtime:   realtime,
+		key:        []byte(signingKey),
+		accessTTL:  cfg.AccessTTL,
+		refreshTTL: cfg.RefreshTTL,
+		log:        log.Named("sessions_service"),
+	}
+}
+
+// Create starts a new session for the user.
+func (s *Service) Create(ctx context.Context, userID, userAgent, ip string) (domain.SessionTokens, error) {
+	refreshToken, refreshHash, err := newRefreshToken()
+	if err != nil {
+		return domain.SessionTokens{}, err
+	}
+
+	now := time.Now().UTC()
+	session := domain.Session{
+		ID:               domain.NewID(),
+		UserID:           userID,
+		RefreshTokenHash: refreshHash,
+		CreatedAt:        now,
+		ExpiresAt:        now.Add(s.refreshTTL),
+		LastUsedAt:       now,
+		UserAgent:        userAgent,
+		IP:               ip,
+	}
+
+	err = s.db.CreateSession(ctx, session)
+	if err != nil {
+		s.log.Error("create session in db", zap.Error(err))
+		return domain.SessionTokens{}, err
+	}
+
+	return s.issue(session, refreshToken)
+}
+
+// Refresh rotates the refresh token and issues a new access token.
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (domain.SessionTokens, error) {
+	session, err := s.db.GetSessionByRefreshHash(ctx, hashRefreshToken(refreshToken))
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.SessionTokens{}, domain.ErrSessionRevoked
+	}
+	if err != nil {
+		s.log.Error("get session from db", zap.Error(err))
+		return domain.SessionTokens{}, err
+	}
+	if !session.Active(time.Now()) {
+		return domain.SessionTokens{}, domain.ErrSessionRevoked
+	}
+
+	newToken, newHash, err := newRefreshToken()
+	if err != nil {
+		return domain.SessionTokens{}, err
+	}
+
+	session.ExpiresAt = time.Now().UTC().Add(s.refreshTTL)
+	err = s.db.RotateRefreshToken(ctx, session.ID, session.RefreshTokenHash, newHash, session.ExpiresAt)
+	if err != nil {
+		if !errors.Is(err, domain.ErrSessionRevoked) {
+			s.log.Error("rotate refresh token in db", zap.Error(err))
+		}
+		return domain.SessionTokens{}, err
+	}
+
+	return s.issue(session, newToken)
+}
+
+// Get returns the session owning the refresh token.
+func (s *Service) Get(ctx context.Context, refreshToken string) (domain.Session, error) {
+	session, err := s.db.GetSessionByRefreshHash(ctx, hashRefreshToken(refreshToken))
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		s.log.Error("get session from db", zap.Error(err))
+	}
+	return session, err
+}
+
+// Validate returns domain.ErrSessionRevoked unless the session is active.
+func (s *Service) Validate(ctx context.Context, sessionID string) error {
+	session, err := s.db.GetSession(ctx, sessionID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.ErrSessionRevoked
+	}
+	if err != nil {
+		s.log.Error("get session from db", zap.Error(err))
+		return err
+	}
+	if !session.Active(time.Now()) {
+		return domain.ErrSessionRevoked
+	}
+	return nil
+}
+
+// Revoke ends a single session and drops realtime connections authorized by it.
+func (s *Service) Revoke(ctx context.Context, sessionID string) error {
+	err := s.db.RevokeSession(ctx, sessionID)
+	if err != nil {
+		s.log.Error("revoke session in db", zap.Error(err))
+		return err
+	}
+
+	err = s.realtime.RevokeToken(ctx, sessionID)
+	if err != nil {
+		s.log.Error("revoke realtime token", zap.String("session_id", sessionID), zap.Error(err))
+	}
+	return nil
+}
+
+// RevokeAll ends every session of the user, i.e. "log out everywhere".
+func (s *Service) RevokeAll(ctx context.Context, userID string) error {
+	sessions, err := s.db.RevokeUserSessions(ctx, userID)
+	if err != nil {
+		s.log.Error("revoke user sessions in db", zap.Error(err))
+		return err
+	}
+
+	err = s.realtime.InvalidateUserTokens(ctx, userID)
+	if err != nil {
+		s.log.Error("invalidate realtime user tokens", zap.String("user_id", userID), zap.Error(err))
+	}
+
+	s.log.Info("revoked user sessions", zap.String("user_id", userID), zap.Int("count", len(sessions)))
+	return nil
+}
+
+func (s *Service) issue(session domain.Session, refreshToken string) (domain.SessionTokens, error) {
+	now := time.Now()
+	accessExpiresAt := now.Add(s.accessTTL)
+
+	claims := jwt.MapClaims{
+		"sub":                 session.UserID,
+		"sid":                 session.ID,
+		domain.TokenTypeClaim: domain.TokenTypeSession,
+		"exp":                 jwt.NewNumericDate(accessExpiresAt),
+		"iat":                 jwt.NewNumericDate(now),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	accessToken, err := token.SignedString(s.key)
+	if err != nil {
+		return domain.SessionTokens{}, err
+	}
+
+	return domain.SessionTokens{
+		SessionID:        session.ID,
+		AccessToken:      accessToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
+func newRefreshToken() (string, string, error) {
+	b := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, hashRefreshToken(token), nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
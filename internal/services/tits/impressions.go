@@ -0,0 +1,83 @@
+package tits
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/boobsrate/core/internal/domain"
+	"go.uber.org/zap"
+)
+
+// impressionsFlushTimeout bounds the last flush after the flusher was stopped.
+const impressionsFlushTimeout = 5 * time.Second
+
+// impressions counts the cards shown in duels until they are flushed to the database,
+// so that serving a pair stays a read.
+type impressions struct {
+	mu      sync.Mutex
+	pending map[string]int64
+}
+
+func newImpressions() *impressions {
+	return &impressions{
+		pending: make(map[string]int64),
+	}
+}
+
+func (i *impressions) add(tits []domain.Tits) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, t := range tits {
+		i.pending[t.ID]++
+	}
+}
+
+// take returns the counted impressions and starts counting from zero.
+func (i *impressions) take() map[string]int64 {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	pending := i.pending
+	i.pending = make(map[string]int64, len(pending))
+	return pending
+}
+
+// restore adds back impressions that couldn't be flushed.
+func (i *impressions) restore(pending map[string]int64) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for titsID, count := range pending {
+		i.pending[titsID] += count
+	}
+}
+
+// FlushImpressions writes the impressions counted since the last flush to the database.
+// They are kept for the next flush if the write fails.
+func (s *Service) FlushImpressions(ctx context.Context) error {
+	pending := s.impressions.take()
+	if err := s.db.AddImpressions(ctx, pending); err != nil {
+		s.impressions.restore(pending)
+		s.log.Error("add impressions in db", zap.Int("tits", len(pending)), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// RunImpressionsFlusher flushes the impressions every interval until ctx is done,
+// then flushes what is left once more.
+func (s *Service) RunImpressionsFlusher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), impressionsFlushTimeout)
+			s.FlushImpressions(flushCtx) // nolint: errcheck
+			cancel()
+			return
+		case <-ticker.C:
+			s.FlushImpressions(ctx) // nolint: errcheck
+		}
+	}
+}
@@ -2,23 +2,40 @@ package tits
 
 import (
 	"context"
+	"time"
 
 	"github.com/boobsrate/core/internal/domain"
 )
 
 type Database interface {
-	GetTits(ctx context.Context) ([]domain.Tits, error)
-	GetTop(ctx context.Context, limit int, abyss bool) ([]domain.Tits, error)
+	GetTits(ctx context.Context, tag string) ([]domain.Tits, error)
+	AddImpressions(ctx context.Context, impressions map[string]int64) error
+	GetTop(ctx context.Context, limit int, abyss bool, tag string) ([]domain.Tits, error)
 	CreateTits(ctx context.Context, tits domain.Tits) error
-	IncreaseRating(ctx context.Context, titsID string) (int64, error)
-	Report(ctx context.Context, titsID string) error
+	SetDetections(ctx context.Context, titsID string, detections []domain.Detection, tags []string) error
+	FindSimilarTits(ctx context.Context, dhash uint64, maxDistance int) (domain.Tits, error)
+	GetOriginalTits(ctx context.Context) ([]domain.Tits, error)
+	SetDHash(ctx context.Context, titsID string, dhash uint64) error
+	MergeDuplicate(ctx context.Context, originalID, duplicateID string) error
+	GetRenditionBackfill(ctx context.Context, afterID string, limit int) ([]domain.Tits, error)
+	SetRenditions(ctx context.Context, titsID string, renditions map[string]domain.ImageRendition) error
+	IncreaseRating(ctx context.Context, vote domain.Vote) (domain.Tits, domain.Tits, error)
+	Report(ctx context.Context, report domain.Report) error
 	GetReportsCount(ctx context.Context, titsID string) (int, error)
-	MoveToAbyss(ctx context.Context, titsID string) error
-	GetTitsWithReportsThreshold(ctx context.Context, reportsThreshold int) ([]domain.Tits, error)
+	GetAbyssCandidates(ctx context.Context, since time.Time) ([]domain.AbyssCandidate, error)
+	GetAbyssCandidate(ctx context.Context, titsID string) (domain.AbyssCandidate, error)
+	GetAbyssHistory(ctx context.Context, titsID string) ([]domain.AbyssEvent, error)
+	GetAbyssRestoreCandidates(ctx context.Context, minVotes int) ([]domain.AbyssRestoreCandidate, error)
+	ApplyAbyssEvent(ctx context.Context, event domain.AbyssEvent) error
 }
 
 type Storage interface {
-	CreateImageFromFile(ctx context.Context, imageName string, filePath string) error
-	CreateImageFromBytes(ctx context.Context, imageName string, imageData []byte) error
+	CreateImageWithContentType(ctx context.Context, imageName string, imageData []byte, contentType string) error
+	GetImage(ctx context.Context, imageName string) ([]byte, error)
 	GetImageUrl(imageID string) string
 }
+
+// ImageProcessor scales images down to a width, keeping the aspect ratio.
+type ImageProcessor interface {
+	Process(ctx context.Context, src domain.ImageSource, width int) (domain.ProcessedImage, error)
+}
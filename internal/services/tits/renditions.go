@@ -0,0 +1,133 @@
+package tits
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/boobsrate/core/internal/domain"
+	"github.com/boobsrate/core/pkg/imageproc"
+	"go.uber.org/zap"
+)
+
+// createRenditions stores the configured renditions of the image that aren't in existing yet
+// and returns all renditions of the card. Every rendition is validated before it's stored.
+func (s *Service) createRenditions(
+	ctx context.Context, titsID string, src domain.ImageSource, existing map[string]domain.ImageRendition,
+) (map[string]domain.ImageRendition, error) {
+	renditions := make(map[string]domain.ImageRendition, len(s.renditions))
+	for name, rendition := range existing {
+		renditions[name] = rendition
+	}
+
+	for _, spec := range s.renditions {
+		if _, ok := renditions[spec.Name]; ok {
+			continue
+		}
+
+		rendition, err := s.createRendition(ctx, titsID, src, spec)
+		if err != nil {
+			s.log.Error("create rendition", zap.String("tits_id", titsID), zap.String("rendition", spec.Name), zap.Error(err))
+			return nil, err
+		}
+		renditions[spec.Name] = rendition
+	}
+	return renditions, nil
+}
+
+func (s *Service) createRendition(ctx context.Context, titsID string, src domain.ImageSource, spec domain.RenditionSpec) (domain.ImageRendition, error) {
+	// The original is already in the storage under the key of the source.
+	if spec.Width == 0 {
+		info, err := imageproc.Validate(src.Data)
+		if err != nil {
+			return domain.ImageRendition{}, fmt.Errorf("invalid original image: %w", err)
+		}
+		return domain.ImageRendition{Key: src.Key, Width: info.Width, Height: info.Height, Format: info.Format}, nil
+	}
+
+	image, err := s.processor.Process(ctx, src, spec.Width)
+	if err != nil {
+		return domain.ImageRendition{}, fmt.Errorf("process image: %w", err)
+	}
+	info, err := imageproc.Validate(image.Data)
+	if err != nil {
+		return domain.ImageRendition{}, fmt.Errorf("invalid processed image of type %q: %w", image.ContentType, err)
+	}
+
+	key := fmt.Sprintf("%s_%s.%s", titsID, spec.Name, extension(info.Format))
+	if err := s.storage.CreateImageWithContentType(ctx, key, image.Data, info.ContentType); err != nil {
+		return domain.ImageRendition{}, err
+	}
+	return domain.ImageRendition{Key: key, Width: info.Width, Height: info.Height, Format: info.Format}, nil
+}
+
+// setURLs fills the URLs of the cards and their renditions. Cards created before renditions
+// were stored link to the .webp preview and the .jpg original.
+func (s *Service) setURLs(tits []domain.Tits) {
+	for idx := range tits {
+		imgPrefix := s.storage.GetImageUrl(tits[idx].ID)
+		tits[idx].URL = fmt.Sprintf("%s.webp", imgPrefix)
+		tits[idx].FullURL = fmt.Sprintf("%s.jpg", imgPrefix)
+
+		for name, rendition := range tits[idx].Renditions {
+			rendition.URL = s.storage.GetImageUrl(rendition.Key)
+			tits[idx].Renditions[name] = rendition
+		}
+		if card, ok := tits[idx].Renditions[domain.RenditionCard]; ok {
+			tits[idx].URL = card.URL
+		}
+		if original, ok := tits[idx].Renditions[domain.RenditionOriginal]; ok {
+			tits[idx].FullURL = original.URL
+		}
+	}
+}
+
+func (s *Service) GetRenditionBackfill(ctx context.Context, afterID string, limit int) ([]domain.Tits, error) {
+	tits, err := s.db.GetRenditionBackfill(ctx, afterID, limit)
+	if err != nil {
+		s.log.Error("get rendition backfill from db", zap.Error(err))
+		return nil, err
+	}
+	return tits, nil
+}
+
+// CreateMissingRenditions renders the configured renditions the card doesn't have yet from its
+// original image and returns how many were created.
+func (s *Service) CreateMissingRenditions(ctx context.Context, tits domain.Tits) (int, error) {
+	missing := 0
+	for _, spec := range s.renditions {
+		if _, ok := tits.Renditions[spec.Name]; !ok {
+			missing++
+		}
+	}
+	if missing == 0 {
+		return 0, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTitsCreateTimeout)
+	defer cancel()
+
+	image, err := s.GetImage(ctx, tits.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	src := domain.ImageSource{Data: image, Key: fmt.Sprintf("%s.jpg", tits.ID)}
+	renditions, err := s.createRenditions(ctx, tits.ID, src, tits.Renditions)
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.db.SetRenditions(ctx, tits.ID, renditions)
+	if err != nil {
+		s.log.Error("set renditions in db", zap.String("tits_id", tits.ID), zap.Error(err))
+		return 0, err
+	}
+	return missing, nil
+}
+
+func extension(format string) string {
+	if format == "jpeg" {
+		return "jpg"
+	}
+	return format
+}
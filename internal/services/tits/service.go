@@ -2,185 +2,198 @@ package tits
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"strings"
 	"time"
 
 	"github.com/boobsrate/core/internal/domain"
+	"github.com/boobsrate/core/pkg/glicko"
+	"github.com/boobsrate/core/pkg/imageproc"
 	"go.uber.org/zap"
 )
 
-const defaultTitsCreateTimeout = time.Second * 60
+const (
+	defaultTitsCreateTimeout = time.Second * 60
+	maxReportCommentLength   = 500
+)
 
 type Service struct {
-	db           Database
-	storage      Storage
-	optimizerURL string
+	db         Database
+	storage    Storage
+	processor  ImageProcessor
+	renditions []domain.RenditionSpec
+
+	impressions *impressions
 
 	wsChannel chan domain.WSMessage
 
 	log *zap.Logger
 }
 
-func NewService(db Database, storage Storage, log *zap.Logger, wsChannel chan domain.WSMessage, optimizerURL string) *Service {
+func NewService(db Database, storage Storage, log *zap.Logger, wsChannel chan domain.WSMessage, processor ImageProcessor, renditions []domain.RenditionSpec) *Service {
 	return &Service{
-		db:           db,
-		storage:      storage,
-		wsChannel:    wsChannel,
-		optimizerURL: optimizerURL,
-		log:          log.Named("tits_service"),
+		db:          db,
+		storage:     storage,
+		wsChannel:   wsChannel,
+		processor:   processor,
+		renditions:  renditions,
+		impressions: newImpressions(),
+		log:         log.Named("tits_service"),
 	}
 }
 
-func (s *Service) getWebpImage(ctx context.Context, filename, url string) ([]byte, error) {
-	httpClient := http.Client{}
-	filenameSplitted := strings.Split(filename, ".")
-	fileUrl := s.storage.GetImageUrl(filenameSplitted[0])
-	requestURL := fmt.Sprintf("%s/optimize?size=350&format=webp&src=http://minio.minio:9000%s.jpg", s.optimizerURL, fileUrl)
-	req, err := http.NewRequest("GET", requestURL, nil)
-	if err != nil {
-		return nil, err
-	}
-	req = req.WithContext(ctx)
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		s.log.Error("Get image from minio to optimize")
-		if url == "" {
-			return nil, err
-		}
-		requestURL = fmt.Sprintf("%s/optimize?size=350&format=webp&src=%s", s.optimizerURL, url)
-		req, err = http.NewRequest("GET", requestURL, nil)
-		if err != nil {
-			return nil, err
-		}
-		req = req.WithContext(ctx)
-		resp, err = httpClient.Do(req)
-		if err != nil {
-			return nil, err
-		}
-	}
-	defer resp.Body.Close()
-	return ioutil.ReadAll(resp.Body)
-}
-
-func (s *Service) CreateTitsFromFile(ctx context.Context, filename, filePath string) error {
+// CreateTitsFromBytes uploads the image and creates a card described by meta.
+// The image is stored with the content type of its actual format, whatever the filename says.
+func (s *Service) CreateTitsFromBytes(ctx context.Context, filename string, file []byte, url string, meta domain.TitsMeta) error {
 	ctx, cancel := context.WithTimeout(ctx, defaultTitsCreateTimeout)
 	defer cancel()
 
-	err := s.storage.CreateImageFromFile(ctx, filename, filePath)
+	info, err := imageproc.Validate(file)
+	if err != nil {
+		s.log.Error("validate tits image", zap.String("filename", filename), zap.Error(err))
+		return fmt.Errorf("invalid image: %w", err)
+	}
+	err = s.storage.CreateImageWithContentType(ctx, filename, file, info.ContentType)
 	if err != nil {
 		s.log.Error("create tits from file:", zap.Error(err))
 		return err
 	}
 
-	webpImage, err := s.getWebpImage(ctx, filename, "")
+	titsID := strings.ReplaceAll(filename, ".jpg", "")
+	renditions, err := s.createRenditions(ctx, titsID, domain.ImageSource{Data: file, Key: filename, URL: url}, nil)
 	if err != nil {
-		s.log.Error("get webp image:", zap.Error(err))
 		return err
 	}
 
-	webpFilename := strings.Replace(filename, ".jpg", ".webp", 1)
-	err = s.storage.CreateImageFromBytes(ctx, webpFilename, webpImage)
+	tits := newTits(titsID)
+	tits.Renditions = renditions
+	tits.Tags = mergeTags(meta.Tags, domain.DetectionTags(meta.Detections))
+	tits.Detections = meta.Detections
+	tits.DHash = meta.DHash
+	tits.DuplicateOf = meta.DuplicateOf
+	err = s.db.CreateTits(ctx, tits)
 	if err != nil {
-		s.log.Error("create webp image:", zap.Error(err))
+		s.log.Error("create tits in db: ", zap.Error(err))
 		return err
 	}
+	return nil
+}
 
-	err = s.db.CreateTits(ctx, domain.Tits{
-		ID:        strings.ReplaceAll(filename, ".jpg", ""),
-		CreatedAt: time.Now().UTC(),
-		Rating:    0,
-	})
+// SetDetections stores the detector results of an existing card and tags it accordingly.
+func (s *Service) SetDetections(ctx context.Context, titsID string, detections []domain.Detection) error {
+	err := s.db.SetDetections(ctx, titsID, detections, domain.DetectionTags(detections))
 	if err != nil {
-		s.log.Error("create tits in db: ", zap.Error(err))
-		//return err
+		s.log.Error("set detections in db", zap.String("tits_id", titsID), zap.Error(err))
+		return err
 	}
 	return nil
 }
 
-func (s *Service) CreateTitsFromBytes(ctx context.Context, filename string, file []byte, url string) error {
-	ctx, cancel := context.WithTimeout(ctx, defaultTitsCreateTimeout)
-	defer cancel()
+// FindDuplicate returns the original card whose image hash is within maxDistance bits, domain.ErrNotFound if there is none.
+func (s *Service) FindDuplicate(ctx context.Context, dhash uint64, maxDistance int) (domain.Tits, error) {
+	tits, err := s.db.FindSimilarTits(ctx, dhash, maxDistance)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		s.log.Error("find similar tits in db", zap.Error(err))
+	}
+	return tits, err
+}
 
-	err := s.storage.CreateImageFromBytes(ctx, filename, file)
+func (s *Service) GetOriginalTits(ctx context.Context) ([]domain.Tits, error) {
+	tits, err := s.db.GetOriginalTits(ctx)
 	if err != nil {
-		s.log.Error("create tits from file:", zap.Error(err))
-		return err
+		s.log.Error("get original tits from db", zap.Error(err))
+		return nil, err
 	}
+	return tits, nil
+}
 
-	webpImage, err := s.getWebpImage(ctx, filename, url)
+// GetImage returns the original image of the card.
+func (s *Service) GetImage(ctx context.Context, titsID string) ([]byte, error) {
+	image, err := s.storage.GetImage(ctx, fmt.Sprintf("%s.jpg", titsID))
 	if err != nil {
-		s.log.Error("get webp image:", zap.Error(err))
-		return err
+		s.log.Error("get image from storage", zap.String("tits_id", titsID), zap.Error(err))
+		return nil, err
 	}
+	return image, nil
+}
 
-	webpFilename := strings.Replace(filename, ".jpg", ".webp", 1)
-	err = s.storage.CreateImageFromBytes(ctx, webpFilename, webpImage)
+func (s *Service) SetDHash(ctx context.Context, titsID string, dhash uint64) error {
+	err := s.db.SetDHash(ctx, titsID, dhash)
 	if err != nil {
-		s.log.Error("create webp image:", zap.Error(err))
+		s.log.Error("set dhash in db", zap.String("tits_id", titsID), zap.Error(err))
 		return err
 	}
+	return nil
+}
 
-	err = s.db.CreateTits(ctx, domain.Tits{
-		ID:        strings.ReplaceAll(filename, ".jpg", ""),
-		CreatedAt: time.Now().UTC(),
-		Rating:    0,
-	})
+func (s *Service) MergeDuplicate(ctx context.Context, originalID, duplicateID string) error {
+	err := s.db.MergeDuplicate(ctx, originalID, duplicateID)
 	if err != nil {
-		s.log.Error("create tits in db: ", zap.Error(err))
-		//return err
+		s.log.Error("merge duplicate in db", zap.String("tits_id", duplicateID), zap.Error(err))
+		return err
 	}
 	return nil
 }
 
-func (s *Service) GetTits(ctx context.Context) ([]domain.Tits, error) {
-	tits, err := s.db.GetTits(ctx)
+func (s *Service) GetTits(ctx context.Context, tag string) ([]domain.Tits, error) {
+	tits, err := s.db.GetTits(ctx, normalizeTag(tag))
 	if err != nil {
 		s.log.Error("get tits from db", zap.Error(err))
 		return nil, err
 	}
 
-	for idx := range tits {
-		imgPrefix := s.storage.GetImageUrl(tits[idx].ID)
-		tits[idx].URL = fmt.Sprintf("%s.webp", imgPrefix)
-		tits[idx].FullURL = fmt.Sprintf("%s.jpg", imgPrefix)
-	}
-
+	s.impressions.add(tits)
+	s.setURLs(tits)
 	return tits, nil
 }
 
-func (s *Service) GetTop(ctx context.Context, limit int, abyss bool) ([]domain.Tits, error) {
-	tits, err := s.db.GetTop(ctx, limit, abyss)
+func (s *Service) GetTop(ctx context.Context, limit int, abyss bool, tag string) ([]domain.Tits, error) {
+	tits, err := s.db.GetTop(ctx, limit, abyss, normalizeTag(tag))
 	if err != nil {
 		s.log.Error("get tits from db", zap.Error(err))
 		return nil, err
 	}
 
-	for idx := range tits {
-		imgPrefix := s.storage.GetImageUrl(tits[idx].ID)
-		tits[idx].URL = fmt.Sprintf("%s.webp", imgPrefix)
-		tits[idx].FullURL = fmt.Sprintf("%s.jpg", imgPrefix)
-	}
-
+	s.setURLs(tits)
 	return tits, nil
 }
 
-func (s *Service) IncreaseRating(ctx context.Context, titsID string) error {
-	newRating, err := s.db.IncreaseRating(ctx, titsID)
+func (s *Service) IncreaseRating(ctx context.Context, vote domain.Vote) error {
+	if vote.UserID == nil || *vote.UserID == "" {
+		return domain.ErrUnauthorized
+	}
+	if vote.TitsID == vote.OpponentID {
+		return domain.ErrInvalidInput
+	}
+
+	winner, loser, err := s.db.IncreaseRating(ctx, vote)
 	if err != nil {
 		s.log.Error("increase rating in db", zap.Error(err))
 		return err
 	}
 
-	go s.sendNewRatingMessage(titsID, newRating)
+	go s.sendNewRatingMessage(winner)
+	go s.sendNewRatingMessage(loser)
 
 	return nil
 }
 
-func (s *Service) Report(ctx context.Context, titsID string) error {
-	err := s.db.Report(ctx, titsID)
+func (s *Service) Report(ctx context.Context, report domain.Report) error {
+	if report.UserID == nil || *report.UserID == "" {
+		return domain.ErrUnauthorized
+	}
+	report.Comment = strings.TrimSpace(report.Comment)
+	if !report.Reason.Valid() || len(report.Comment) > maxReportCommentLength {
+		return domain.ErrInvalidInput
+	}
+	if report.Reason == domain.ReportReasonOther && report.Comment == "" {
+		return domain.ErrInvalidInput
+	}
+
+	report.ID = domain.NewID()
+	report.Status = domain.ReportStatusOpen
+	err := s.db.Report(ctx, report)
 	if err != nil {
 		s.log.Error("report tits in db", zap.Error(err))
 		return err
@@ -199,31 +212,90 @@ func (s *Service) GetReports(ctx context.Context, titsID string) (int, error) {
 	return reports, nil
 }
 
-func (s *Service) MoveToAbyss(ctx context.Context, titsID string) error {
-	err := s.db.MoveToAbyss(ctx, titsID)
+func (s *Service) GetAbyssCandidates(ctx context.Context, since time.Time) ([]domain.AbyssCandidate, error) {
+	candidates, err := s.db.GetAbyssCandidates(ctx, since)
 	if err != nil {
-		s.log.Error("move to abyss in db", zap.Error(err))
-		return err
+		s.log.Error("get abyss candidates from db", zap.Error(err))
+		return nil, err
 	}
-	return nil
+
+	return candidates, nil
+}
+
+func (s *Service) GetAbyssCandidate(ctx context.Context, titsID string) (domain.AbyssCandidate, error) {
+	candidate, err := s.db.GetAbyssCandidate(ctx, titsID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		s.log.Error("get abyss candidate from db", zap.String("tits_id", titsID), zap.Error(err))
+	}
+	return candidate, err
 }
 
-func (s *Service) GetTitsWithReportsThreshold(ctx context.Context, reportsThreshold int) ([]domain.Tits, error) {
-	tits, err := s.db.GetTitsWithReportsThreshold(ctx, reportsThreshold)
+func (s *Service) GetAbyssHistory(ctx context.Context, titsID string) ([]domain.AbyssEvent, error) {
+	events, err := s.db.GetAbyssHistory(ctx, titsID)
 	if err != nil {
-		s.log.Error("get tits from db", zap.Error(err))
+		s.log.Error("get abyss history from db", zap.String("tits_id", titsID), zap.Error(err))
 		return nil, err
 	}
+	return events, nil
+}
 
-	return tits, nil
+func (s *Service) GetAbyssRestoreCandidates(ctx context.Context, minVotes int) ([]domain.AbyssRestoreCandidate, error) {
+	candidates, err := s.db.GetAbyssRestoreCandidates(ctx, minVotes)
+	if err != nil {
+		s.log.Error("get abyss restore candidates from db", zap.Error(err))
+		return nil, err
+	}
+
+	return candidates, nil
+}
+
+func (s *Service) ApplyAbyssEvent(ctx context.Context, event domain.AbyssEvent) error {
+	err := s.db.ApplyAbyssEvent(ctx, event)
+	if err != nil {
+		s.log.Error("apply abyss event in db", zap.String("tits_id", event.TitsID), zap.Error(err))
+		return err
+	}
+
+	return nil
 }
 
-func (s *Service) sendNewRatingMessage(titsID string, newRating int64) {
+func (s *Service) sendNewRatingMessage(tits domain.Tits) {
 	s.wsChannel <- domain.WSMessage{
 		Type: domain.WSMessageTypeNewRating,
 		Message: domain.WSNewRatingMessage{
-			TitsID:    titsID,
-			NewRating: newRating,
+			TitsID:    tits.ID,
+			NewRating: tits.Rating,
+			NewScore:  tits.Score,
 		},
 	}
 }
+
+func newTits(id string) domain.Tits {
+	rating := glicko.NewRating()
+	return domain.Tits{
+		ID:         id,
+		CreatedAt:  time.Now().UTC(),
+		Score:      rating.Rating,
+		Deviation:  rating.Deviation,
+		Volatility: rating.Volatility,
+	}
+}
+
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+func mergeTags(tags ...[]string) []string {
+	var merged []string
+	seen := make(map[string]bool)
+	for _, group := range tags {
+		for _, tag := range group {
+			if seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			merged = append(merged, tag)
+		}
+	}
+	return merged
+}
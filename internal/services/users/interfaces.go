@@ -0,0 +1,14 @@
+package users
+
+import (
+	"context"
+
+	"github.com/boobsrate/core/internal/domain"
+)
+
+type Database interface {
+	UpsertTelegramUser(ctx context.Context, user domain.User) (domain.User, error)
+	GetUser(ctx context.Context, userID string) (domain.User, error)
+	GetUserStats(ctx context.Context, userID string) (domain.UserStats, error)
+	IncrementChatMessages(ctx context.Context, userID string) error
+}
@@ -0,0 +1,74 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/boobsrate/core/internal/domain"
+	"go.uber.org/zap"
+)
+
+type Service struct {
+	db Database
+
+	log *zap.Logger
+}
+
+func NewService(db Database, log *zap.Logger) *Service {
+	return &Service{
+		db:  db,
+		log: log.Named("users_service"),
+	}
+}
+
+// LoginTelegram creates the user on the first telegram login and refreshes the profile on subsequent ones.
+func (s *Service) LoginTelegram(ctx context.Context, user domain.User) (domain.User, error) {
+	now := time.Now().UTC()
+	user.ID = domain.NewID()
+	user.CreatedAt = now
+	user.LastSeenAt = now
+	user.Role = domain.UserRoleUser
+
+	user, err := s.db.UpsertTelegramUser(ctx, user)
+	if err != nil {
+		s.log.Error("upsert telegram user in db", zap.Error(err))
+		return domain.User{}, err
+	}
+	return user, nil
+}
+
+func (s *Service) GetUser(ctx context.Context, userID string) (domain.User, error) {
+	user, err := s.db.GetUser(ctx, userID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		s.log.Error("get user from db", zap.Error(err))
+	}
+	return user, err
+}
+
+func (s *Service) GetProfile(ctx context.Context, userID string) (domain.UserProfile, error) {
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return domain.UserProfile{}, err
+	}
+
+	stats, err := s.db.GetUserStats(ctx, userID)
+	if err != nil {
+		s.log.Error("get user stats from db", zap.Error(err))
+		return domain.UserProfile{}, err
+	}
+
+	return domain.UserProfile{
+		User:  user,
+		Stats: stats,
+	}, nil
+}
+
+func (s *Service) CountChatMessage(ctx context.Context, userID string) error {
+	err := s.db.IncrementChatMessages(ctx, userID)
+	if err != nil {
+		s.log.Error("increment chat messages in db", zap.Error(err))
+		return err
+	}
+	return nil
+}
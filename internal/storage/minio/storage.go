@@ -4,14 +4,11 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 
 	"github.com/minio/minio-go/v7"
 )
 
-const (
-	titsContentTypeJpeg = "image/jpeg"
-)
-
 type Storage struct {
 	client     *minio.Client
 	bucketName string
@@ -26,9 +23,11 @@ func NewMinioStorage(client *minio.Client, bucketName string, publicURL string)
 	}
 }
 
-func (t *Storage) CreateImageFromFile(ctx context.Context, imageName string, filePath string) error {
-	_, err := t.client.FPutObject(
-		ctx, t.bucketName, imageName, filePath, minio.PutObjectOptions{ContentType: titsContentTypeJpeg},
+func (t *Storage) CreateImageWithContentType(ctx context.Context, imageName string, imageData []byte, contentType string) error {
+	reader := bytes.NewReader(imageData)
+
+	_, err := t.client.PutObject(
+		ctx, t.bucketName, imageName, reader, int64(len(imageData)), minio.PutObjectOptions{ContentType: contentType},
 	)
 	if err != nil {
 		return fmt.Errorf("upload image to minio: %v", err)
@@ -36,17 +35,18 @@ func (t *Storage) CreateImageFromFile(ctx context.Context, imageName string, fil
 	return nil
 }
 
-func (t *Storage) CreateImageFromBytes(ctx context.Context, imageName string, imageData []byte) error {
-
-	reader := bytes.NewReader(imageData)
+func (t *Storage) GetImage(ctx context.Context, imageName string) ([]byte, error) {
+	object, err := t.client.GetObject(ctx, t.bucketName, imageName, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("get image from minio: %v", err)
+	}
+	defer object.Close()
 
-	_, err := t.client.PutObject(
-		ctx, t.bucketName, imageName, reader, int64(len(imageData)), minio.PutObjectOptions{ContentType: titsContentTypeJpeg},
-	)
+	imageData, err := io.ReadAll(object)
 	if err != nil {
-		return fmt.Errorf("upload image to minio: %v", err)
+		return nil, fmt.Errorf("read image from minio: %v", err)
 	}
-	return nil
+	return imageData, nil
 }
 
 func (t *Storage) DeleteImage(ctx context.Context, imageName string) error {
@@ -0,0 +1,11 @@
+BEGIN;
+
+DROP INDEX IF EXISTS tits_conservative_score_idx;
+
+ALTER TABLE votes DROP COLUMN opponent_id;
+
+ALTER TABLE tits DROP COLUMN volatility;
+ALTER TABLE tits DROP COLUMN deviation;
+ALTER TABLE tits DROP COLUMN score;
+
+COMMIT;
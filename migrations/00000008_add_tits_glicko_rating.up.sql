@@ -0,0 +1,11 @@
+BEGIN;
+
+ALTER TABLE tits ADD COLUMN score      DOUBLE PRECISION NOT NULL DEFAULT 1500;
+ALTER TABLE tits ADD COLUMN deviation  DOUBLE PRECISION NOT NULL DEFAULT 350;
+ALTER TABLE tits ADD COLUMN volatility DOUBLE PRECISION NOT NULL DEFAULT 0.06;
+
+ALTER TABLE votes ADD COLUMN opponent_id TEXT;
+
+CREATE INDEX tits_conservative_score_idx ON tits ((score - 2 * deviation) DESC);
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS votes_duel_id_uniq;
+
+ALTER TABLE votes DROP COLUMN duel_id;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE votes ADD COLUMN duel_id TEXT;
+
+CREATE UNIQUE INDEX votes_duel_id_uniq ON votes (duel_id) WHERE duel_id IS NOT NULL;
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP INDEX IF EXISTS reports_user_tits_uniq;
+DROP INDEX IF EXISTS votes_user_pair_uniq;
+
+ALTER TABLE reports ALTER COLUMN user_id TYPE BIGINT USING CASE WHEN user_id ~ '^[0-9]+$' THEN user_id::BIGINT END;
+ALTER TABLE votes ALTER COLUMN user_id TYPE BIGINT USING CASE WHEN user_id ~ '^[0-9]+$' THEN user_id::BIGINT END;
+
+COMMIT;
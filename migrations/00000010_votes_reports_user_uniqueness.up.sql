@@ -0,0 +1,11 @@
+BEGIN;
+
+ALTER TABLE votes ALTER COLUMN user_id TYPE TEXT USING user_id::TEXT;
+ALTER TABLE reports ALTER COLUMN user_id TYPE TEXT USING user_id::TEXT;
+
+CREATE UNIQUE INDEX votes_user_pair_uniq ON votes (user_id, LEAST(tits_id, opponent_id), GREATEST(tits_id, opponent_id))
+    WHERE user_id IS NOT NULL AND opponent_id IS NOT NULL;
+
+CREATE UNIQUE INDEX reports_user_tits_uniq ON reports (user_id, tits_id) WHERE user_id IS NOT NULL;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS votes_user_id_idx;
+
+DROP TABLE IF EXISTS users;
+
+COMMIT;
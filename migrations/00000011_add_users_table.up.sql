@@ -0,0 +1,21 @@
+BEGIN;
+
+CREATE TABLE users
+(
+    id            TEXT        NOT NULL,
+    telegram_id   BIGINT      UNIQUE,
+    username      TEXT        NOT NULL DEFAULT '',
+    display_name  TEXT        NOT NULL DEFAULT '',
+    avatar_url    TEXT        NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ NOT NULL,
+    last_seen_at  TIMESTAMPTZ NOT NULL,
+    role          TEXT        NOT NULL DEFAULT 'user',
+    banned        BOOLEAN     NOT NULL DEFAULT FALSE,
+    chat_messages BIGINT      NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX votes_user_id_idx ON votes (user_id);
+
+COMMIT;
@@ -0,0 +1,20 @@
+BEGIN;
+
+CREATE TABLE sessions
+(
+    id                 TEXT        NOT NULL,
+    user_id            TEXT        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    refresh_token_hash TEXT        NOT NULL UNIQUE,
+    created_at         TIMESTAMPTZ NOT NULL,
+    expires_at         TIMESTAMPTZ NOT NULL,
+    last_used_at       TIMESTAMPTZ NOT NULL,
+    revoked_at         TIMESTAMPTZ,
+    user_agent         TEXT        NOT NULL DEFAULT '',
+    ip                 TEXT        NOT NULL DEFAULT '',
+
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX sessions_user_id_idx ON sessions (user_id);
+
+COMMIT;
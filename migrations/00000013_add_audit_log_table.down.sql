@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS reports_tits_id_idx;
+
+DROP TABLE IF EXISTS audit_log;
+
+COMMIT;
@@ -0,0 +1,19 @@
+BEGIN;
+
+CREATE TABLE audit_log
+(
+    id         TEXT        NOT NULL,
+    actor_id   TEXT        NOT NULL,
+    action     TEXT        NOT NULL,
+    target_id  TEXT        NOT NULL,
+    details    JSONB,
+    created_at TIMESTAMPTZ NOT NULL,
+
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX audit_log_target_id_idx ON audit_log (target_id);
+
+CREATE INDEX reports_tits_id_idx ON reports (tits_id);
+
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+
+DROP INDEX IF EXISTS reports_status_created_at_idx;
+
+ALTER TABLE reports DROP COLUMN IF EXISTS decided_at;
+ALTER TABLE reports DROP COLUMN IF EXISTS decided_by;
+ALTER TABLE reports DROP COLUMN IF EXISTS status;
+ALTER TABLE reports DROP COLUMN IF EXISTS comment;
+ALTER TABLE reports DROP COLUMN IF EXISTS reason;
+ALTER TABLE reports DROP COLUMN IF EXISTS id;
+
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN;
+
+ALTER TABLE reports ADD COLUMN id TEXT;
+UPDATE reports SET id = md5(random()::TEXT || clock_timestamp()::TEXT) WHERE id IS NULL;
+ALTER TABLE reports ALTER COLUMN id SET NOT NULL;
+ALTER TABLE reports ADD PRIMARY KEY (id);
+
+ALTER TABLE reports ADD COLUMN reason TEXT NOT NULL DEFAULT 'other';
+ALTER TABLE reports ADD COLUMN comment TEXT NOT NULL DEFAULT '';
+ALTER TABLE reports ADD COLUMN status TEXT NOT NULL DEFAULT 'open';
+ALTER TABLE reports ADD COLUMN decided_by TEXT;
+ALTER TABLE reports ADD COLUMN decided_at TIMESTAMPTZ;
+
+CREATE INDEX reports_status_created_at_idx ON reports (status, created_at);
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS abyss_history;
+
+ALTER TABLE tits DROP COLUMN IF EXISTS impressions;
+
+COMMIT;
@@ -0,0 +1,20 @@
+BEGIN;
+
+ALTER TABLE tits ADD COLUMN impressions BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE abyss_history
+(
+    id         TEXT        NOT NULL,
+    tits_id    TEXT        NOT NULL,
+    action     TEXT        NOT NULL,
+    policy     TEXT        NOT NULL,
+    actor_id   TEXT,
+    inputs     JSONB,
+    created_at TIMESTAMPTZ NOT NULL,
+
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX abyss_history_tits_id_idx ON abyss_history (tits_id, created_at);
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP TABLE IF EXISTS abyss_votes;
+
+DROP TABLE IF EXISTS abyss_appeals;
+
+ALTER TABLE reports DROP COLUMN IF EXISTS archived_at;
+
+COMMIT;
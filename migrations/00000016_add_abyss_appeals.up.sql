@@ -0,0 +1,30 @@
+BEGIN;
+
+ALTER TABLE reports ADD COLUMN archived_at TIMESTAMPTZ;
+
+CREATE TABLE abyss_appeals
+(
+    id         TEXT        NOT NULL,
+    tits_id    TEXT        NOT NULL,
+    opened_by  TEXT        NOT NULL,
+    reason     TEXT        NOT NULL DEFAULT '',
+    status     TEXT        NOT NULL DEFAULT 'open',
+    created_at TIMESTAMPTZ NOT NULL,
+    decided_by TEXT,
+    decided_at TIMESTAMPTZ,
+
+    PRIMARY KEY (id)
+);
+
+CREATE UNIQUE INDEX abyss_appeals_open_uniq ON abyss_appeals (tits_id) WHERE status = 'open';
+
+CREATE TABLE abyss_votes
+(
+    tits_id    TEXT        NOT NULL,
+    user_id    TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+
+    PRIMARY KEY (tits_id, user_id)
+);
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP INDEX IF EXISTS tasks_claimable_idx;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS lease_expires_at;
+ALTER TABLE tasks DROP COLUMN IF EXISTS claimed_by;
+
+COMMIT;
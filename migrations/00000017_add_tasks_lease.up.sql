@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE tasks ADD COLUMN claimed_by TEXT;
+ALTER TABLE tasks ADD COLUMN lease_expires_at TIMESTAMPTZ;
+
+CREATE INDEX tasks_claimable_idx ON tasks (created_at) WHERE processed = FALSE;
+
+COMMIT;
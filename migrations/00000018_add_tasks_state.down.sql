@@ -0,0 +1,22 @@
+BEGIN;
+
+DROP INDEX IF EXISTS tasks_claimable_idx;
+
+ALTER TABLE tasks ADD COLUMN processed BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE tasks ADD COLUMN need_retry BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE tasks ADD COLUMN status TEXT NOT NULL DEFAULT '';
+
+UPDATE tasks
+SET processed  = state IN ('done', 'rejected_by_detector', 'failed_permanent'),
+    need_retry = state = 'retry_scheduled';
+
+ALTER TABLE tasks ALTER COLUMN error DROP NOT NULL;
+ALTER TABLE tasks ALTER COLUMN error DROP DEFAULT;
+
+ALTER TABLE tasks DROP COLUMN next_attempt_at;
+ALTER TABLE tasks DROP COLUMN attempts;
+ALTER TABLE tasks DROP COLUMN state;
+
+CREATE INDEX tasks_claimable_idx ON tasks (created_at) WHERE processed = FALSE;
+
+COMMIT;
@@ -0,0 +1,34 @@
+BEGIN;
+
+ALTER TABLE tasks ADD COLUMN state TEXT NOT NULL DEFAULT 'pending';
+ALTER TABLE tasks ADD COLUMN attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE tasks ADD COLUMN next_attempt_at TIMESTAMPTZ;
+
+UPDATE tasks
+SET state    = CASE
+                   WHEN processed AND error LIKE 'Detection score is above threshold%' THEN 'rejected_by_detector'
+                   WHEN processed AND COALESCE(error, '') <> '' THEN 'failed_permanent'
+                   WHEN processed THEN 'done'
+                   WHEN need_retry THEN 'retry_scheduled'
+                   ELSE 'pending'
+    END,
+    attempts = CASE
+                   WHEN processed AND need_retry THEN 2
+                   WHEN processed OR need_retry THEN 1
+                   ELSE 0
+        END;
+
+UPDATE tasks SET error = '' WHERE error IS NULL;
+ALTER TABLE tasks ALTER COLUMN error SET DEFAULT '';
+ALTER TABLE tasks ALTER COLUMN error SET NOT NULL;
+
+DROP INDEX IF EXISTS tasks_claimable_idx;
+
+ALTER TABLE tasks DROP COLUMN processed;
+ALTER TABLE tasks DROP COLUMN need_retry;
+ALTER TABLE tasks DROP COLUMN status;
+
+CREATE INDEX tasks_claimable_idx ON tasks (next_attempt_at NULLS FIRST, created_at)
+    WHERE state NOT IN ('done', 'rejected_by_detector', 'failed_permanent');
+
+COMMIT;
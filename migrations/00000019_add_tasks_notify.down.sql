@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS tasks_created_notify ON tasks;
+
+DROP FUNCTION IF EXISTS notify_tasks_created();
+
+COMMIT;
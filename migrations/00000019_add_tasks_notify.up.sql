@@ -0,0 +1,17 @@
+BEGIN;
+
+CREATE OR REPLACE FUNCTION notify_tasks_created() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM pg_notify('tasks_created', '');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tasks_created_notify
+    AFTER INSERT
+    ON tasks
+    FOR EACH STATEMENT
+EXECUTE FUNCTION notify_tasks_created();
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS metadata;
+ALTER TABLE tasks DROP COLUMN IF EXISTS category;
+ALTER TABLE tasks DROP COLUMN IF EXISTS source;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE tasks ADD COLUMN source TEXT NOT NULL DEFAULT '';
+ALTER TABLE tasks ADD COLUMN category TEXT NOT NULL DEFAULT '';
+ALTER TABLE tasks ADD COLUMN metadata JSONB;
+
+COMMIT;
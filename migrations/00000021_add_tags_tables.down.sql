@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS tits_tags;
+DROP TABLE IF EXISTS tags;
+
+COMMIT;
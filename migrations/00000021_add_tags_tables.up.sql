@@ -0,0 +1,21 @@
+BEGIN;
+
+CREATE TABLE tags
+(
+    name       TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    PRIMARY KEY (name)
+);
+
+CREATE TABLE tits_tags
+(
+    tits_id TEXT NOT NULL REFERENCES tits (id) ON DELETE CASCADE,
+    tag     TEXT NOT NULL REFERENCES tags (name) ON DELETE CASCADE,
+
+    PRIMARY KEY (tits_id, tag)
+);
+
+CREATE INDEX tits_tags_tag_idx ON tits_tags (tag);
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE tits DROP COLUMN IF EXISTS detections;
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE tits ADD COLUMN detections JSONB;
+
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+
+CREATE TABLE detection_cache
+(
+    image_sha256 TEXT        NOT NULL,
+    result       JSONB       NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL,
+    expires_at   TIMESTAMPTZ NOT NULL,
+
+    PRIMARY KEY (image_sha256)
+);
+
+CREATE INDEX detection_cache_expires_at_idx ON detection_cache (expires_at);
+
+COMMIT;
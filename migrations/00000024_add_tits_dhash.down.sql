@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS tits_duplicate_of_idx;
+ALTER TABLE tits DROP COLUMN IF EXISTS duplicate_of;
+ALTER TABLE tits DROP COLUMN IF EXISTS dhash;
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE tits ADD COLUMN dhash BIGINT;
+ALTER TABLE tits ADD COLUMN duplicate_of TEXT;
+
+CREATE INDEX tits_duplicate_of_idx ON tits (duplicate_of) WHERE duplicate_of IS NOT NULL;
+
+COMMIT;
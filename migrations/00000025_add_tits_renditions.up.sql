@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE tits ADD COLUMN renditions JSONB;
+
+COMMIT;
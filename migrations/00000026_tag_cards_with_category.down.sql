@@ -0,0 +1,6 @@
+BEGIN;
+
+-- Segments never contain separators, only whole categories do.
+DELETE FROM tags WHERE name LIKE '%\_%' OR name LIKE '%/%';
+
+COMMIT;
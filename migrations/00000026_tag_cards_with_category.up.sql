@@ -0,0 +1,18 @@
+BEGIN;
+
+-- Cards created so far were only tagged with the segments of their category.
+INSERT INTO tags (name)
+SELECT DISTINCT LOWER(TRIM(tasks.category))
+FROM tasks
+         JOIN tits ON tits.id = tasks.id
+WHERE TRIM(tasks.category) <> ''
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO tits_tags (tits_id, tag)
+SELECT tasks.id, LOWER(TRIM(tasks.category))
+FROM tasks
+         JOIN tits ON tits.id = tasks.id
+WHERE TRIM(tasks.category) <> ''
+ON CONFLICT DO NOTHING;
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+-- The explicit tags can't be told apart from the ones set before, they are left in place.
+
+COMMIT;
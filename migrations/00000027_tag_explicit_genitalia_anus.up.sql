@@ -0,0 +1,18 @@
+BEGIN;
+
+-- Exposed male genitalia and anus now make a card explicit too.
+INSERT INTO tags (name)
+VALUES ('explicit')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO tits_tags (tits_id, tag)
+SELECT DISTINCT tits.id, 'explicit'
+FROM tits,
+     jsonb_array_elements(
+             CASE WHEN jsonb_typeof(tits.detections) = 'array' THEN tits.detections ELSE '[]'::JSONB END
+     ) AS detection
+WHERE detection ->> 'class' IN ('MALE_GENITALIA_EXPOSED', 'ANUS_EXPOSED')
+  AND (detection ->> 'score')::DOUBLE PRECISION >= 0.5
+ON CONFLICT DO NOTHING;
+
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+
+-- The same image may be cached for several backends, which the old key can't hold.
+DELETE FROM detection_cache;
+
+ALTER TABLE detection_cache
+    DROP CONSTRAINT detection_cache_pkey;
+
+ALTER TABLE detection_cache
+    DROP COLUMN backend;
+
+ALTER TABLE detection_cache
+    ADD PRIMARY KEY (image_sha256);
+
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+
+-- Rows cached before the backend was recorded can't be attributed to one, so they are dropped.
+DELETE FROM detection_cache;
+
+ALTER TABLE detection_cache
+    ADD COLUMN backend TEXT NOT NULL;
+
+ALTER TABLE detection_cache
+    DROP CONSTRAINT detection_cache_pkey;
+
+ALTER TABLE detection_cache
+    ADD PRIMARY KEY (backend, image_sha256);
+
+COMMIT;
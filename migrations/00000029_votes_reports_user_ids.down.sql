@@ -0,0 +1,5 @@
+BEGIN;
+
+-- The rewrite can't be told apart from votes and reports recorded under the user ID, so it is kept.
+
+COMMIT;
@@ -0,0 +1,28 @@
+BEGIN;
+
+-- Votes and reports recorded before users existed carry the Telegram ID. Move them to the user ID,
+-- except the ones the user already repeated under the user ID.
+UPDATE votes AS vote
+SET user_id = users.id
+FROM users
+WHERE users.telegram_id IS NOT NULL
+  AND vote.user_id = users.telegram_id::TEXT
+  AND NOT EXISTS (SELECT 1
+                  FROM votes AS other
+                  WHERE other.user_id = users.id
+                    AND other.opponent_id IS NOT NULL
+                    AND vote.opponent_id IS NOT NULL
+                    AND LEAST(other.tits_id, other.opponent_id) = LEAST(vote.tits_id, vote.opponent_id)
+                    AND GREATEST(other.tits_id, other.opponent_id) = GREATEST(vote.tits_id, vote.opponent_id));
+
+UPDATE reports AS report
+SET user_id = users.id
+FROM users
+WHERE users.telegram_id IS NOT NULL
+  AND report.user_id = users.telegram_id::TEXT
+  AND NOT EXISTS (SELECT 1
+                  FROM reports AS other
+                  WHERE other.user_id = users.id
+                    AND other.tits_id = report.tits_id);
+
+COMMIT;
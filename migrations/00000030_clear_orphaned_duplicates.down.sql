@@ -0,0 +1,5 @@
+BEGIN;
+
+-- The originals are gone, there is nothing to point the cards back at.
+
+COMMIT;
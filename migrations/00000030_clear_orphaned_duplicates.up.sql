@@ -0,0 +1,9 @@
+BEGIN;
+
+-- Deleting an original card left its duplicates pointing at a missing card, which hid them for good.
+UPDATE tits
+SET duplicate_of = NULL
+WHERE duplicate_of IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM tits AS original WHERE original.id = tits.duplicate_of);
+
+COMMIT;
@@ -0,0 +1,121 @@
+package breaker
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Breaker is a circuit breaker that opens after a number of consecutive failures.
+// Once the cooldown is over a single probe call is let through: its success closes
+// the breaker, its failure opens it for another cooldown.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+
+	onChange func(open bool)
+}
+
+// New returns a closed breaker. onChange, if not nil, is called whenever the breaker opens or closes.
+func New(threshold int, cooldown time.Duration, onChange func(open bool)) *Breaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		onChange:  onChange,
+	}
+}
+
+// Allow reports whether a call may go through.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.openUntil.IsZero() {
+		return true
+	}
+	if b.probing || time.Now().Before(b.openUntil) {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// Success records a successful call and closes the breaker.
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	wasOpen := !b.openUntil.IsZero()
+	b.failures = 0
+	b.openUntil = time.Time{}
+	b.probing = false
+	if wasOpen && b.onChange != nil {
+		b.onChange(false)
+	}
+}
+
+// Failure records a failed call and opens the breaker once the threshold is reached.
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if !b.probing && b.failures < b.threshold {
+		return
+	}
+
+	wasOpen := !b.openUntil.IsZero()
+	b.openUntil = time.Now().Add(b.cooldown)
+	b.probing = false
+	if !wasOpen && b.onChange != nil {
+		b.onChange(true)
+	}
+}
+
+// Release gives up a call that ended without an outcome, e.g. because it was canceled,
+// so that a probe it was holding doesn't block the breaker.
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// probePoll is how often Wait checks whether a running probe has finished.
+const probePoll = time.Second
+
+// Wait blocks while the breaker doesn't let calls through or until ctx is done.
+func (b *Breaker) Wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		openUntil, probing := b.openUntil, b.probing
+		b.mu.Unlock()
+
+		if openUntil.IsZero() {
+			return nil
+		}
+		wait := time.Until(openUntil)
+		if wait <= 0 {
+			if !probing {
+				return nil
+			}
+			wait = probePoll
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
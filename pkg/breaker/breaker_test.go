@@ -0,0 +1,121 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+const testCooldown = 20 * time.Millisecond
+
+// step is an operation on the breaker. For allow, want is the expected result.
+type step struct {
+	op   string
+	want bool
+}
+
+var (
+	allow   = step{op: "allow", want: true}
+	deny    = step{op: "allow", want: false}
+	success = step{op: "success"}
+	failure = step{op: "failure"}
+	release = step{op: "release"}
+	cool    = step{op: "cool"}
+)
+
+func TestBreaker(t *testing.T) {
+	tests := []struct {
+		name    string
+		steps   []step
+		changes []bool
+	}{
+		{
+			name:  "stays closed below the threshold",
+			steps: []step{failure, failure, allow, success, failure, failure, allow},
+		},
+		{
+			name:    "opens at the threshold",
+			steps:   []step{failure, failure, failure, deny},
+			changes: []bool{true},
+		},
+		{
+			name:    "lets a single probe through after the cooldown",
+			steps:   []step{failure, failure, failure, cool, allow, deny},
+			changes: []bool{true},
+		},
+		{
+			name:    "closes on a successful probe",
+			steps:   []step{failure, failure, failure, cool, allow, success, allow, allow},
+			changes: []bool{true, false},
+		},
+		{
+			name:    "reopens on a failed probe",
+			steps:   []step{failure, failure, failure, cool, allow, failure, deny, cool, allow},
+			changes: []bool{true},
+		},
+		{
+			name:    "released probe lets another one through",
+			steps:   []step{failure, failure, failure, cool, allow, deny, release, allow},
+			changes: []bool{true},
+		},
+		{
+			name:  "success resets the failure count",
+			steps: []step{failure, failure, success, failure, failure, allow},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var changes []bool
+			b := New(3, testCooldown, func(open bool) { changes = append(changes, open) })
+
+			for i, s := range tt.steps {
+				switch s.op {
+				case "allow":
+					if got := b.Allow(); got != s.want {
+						t.Fatalf("step %d: Allow() = %v, want %v", i, got, s.want)
+					}
+				case "success":
+					b.Success()
+				case "failure":
+					b.Failure()
+				case "release":
+					b.Release()
+				case "cool":
+					time.Sleep(testCooldown + 10*time.Millisecond)
+				}
+			}
+
+			if len(changes) != len(tt.changes) {
+				t.Fatalf("changes = %v, want %v", changes, tt.changes)
+			}
+			for i := range changes {
+				if changes[i] != tt.changes[i] {
+					t.Fatalf("changes = %v, want %v", changes, tt.changes)
+				}
+			}
+		})
+	}
+}
+
+func TestBreakerWait(t *testing.T) {
+	b := New(1, testCooldown, nil)
+	if err := b.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() on a closed breaker = %v", err)
+	}
+
+	b.Failure()
+	ctx, cancel := context.WithTimeout(context.Background(), testCooldown/4)
+	defer cancel()
+	if err := b.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait() on an open breaker = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	if err := b.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() until the cooldown is over = %v", err)
+	}
+	if !b.Allow() {
+		t.Fatal("Allow() after Wait() = false, want a probe")
+	}
+}
@@ -0,0 +1,110 @@
+package glicko
+
+import (
+	"math"
+)
+
+// Glicko-2 system constants, see http://www.glicko.net/glicko/glicko2.pdf.
+const (
+	DefaultRating     = 1500.0
+	DefaultDeviation  = 350.0
+	DefaultVolatility = 0.06
+
+	scale            = 173.7178
+	tau              = 0.5
+	convergenceDelta = 0.000001
+	maxDeviation     = DefaultDeviation
+)
+
+// Rating is a Glicko-2 rating of a single player.
+type Rating struct {
+	Rating     float64
+	Deviation  float64
+	Volatility float64
+}
+
+// NewRating returns a rating of a player that has never played.
+func NewRating() Rating {
+	return Rating{
+		Rating:     DefaultRating,
+		Deviation:  DefaultDeviation,
+		Volatility: DefaultVolatility,
+	}
+}
+
+// Duel updates both ratings after a single game where winner has beaten loser.
+// Every duel is treated as its own rating period.
+func Duel(winner, loser Rating) (Rating, Rating) {
+	return update(winner, loser, 1), update(loser, winner, 0)
+}
+
+func update(player, opponent Rating, score float64) Rating {
+	mu := (player.Rating - DefaultRating) / scale
+	phi := player.Deviation / scale
+	sigma := player.Volatility
+
+	muJ := (opponent.Rating - DefaultRating) / scale
+	phiJ := opponent.Deviation / scale
+
+	gJ := g(phiJ)
+	e := expected(mu, muJ, gJ)
+
+	v := 1 / (gJ * gJ * e * (1 - e))
+	delta := v * gJ * (score - e)
+
+	newSigma := volatility(phi, sigma, v, delta)
+
+	phiStar := math.Sqrt(phi*phi + newSigma*newSigma)
+	newPhi := 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	newMu := mu + newPhi*newPhi*gJ*(score-e)
+
+	return Rating{
+		Rating:     newMu*scale + DefaultRating,
+		Deviation:  math.Min(newPhi*scale, maxDeviation),
+		Volatility: newSigma,
+	}
+}
+
+func g(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+func expected(mu, muJ, gJ float64) float64 {
+	return 1 / (1 + math.Exp(-gJ*(mu-muJ)))
+}
+
+// volatility finds the new volatility using the Illinois algorithm (step 5 of the paper).
+func volatility(phi, sigma, v, delta float64) float64 {
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		d := phi*phi + v + ex
+		return ex*(delta*delta-phi*phi-v-ex)/(2*d*d) - (x-a)/(tau*tau)
+	}
+
+	lower := a
+	var upper float64
+	if delta*delta > phi*phi+v {
+		upper = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+		for f(a-k*tau) < 0 {
+			k++
+		}
+		upper = a - k*tau
+	}
+
+	fLower, fUpper := f(lower), f(upper)
+	for math.Abs(upper-lower) > convergenceDelta {
+		c := lower + (lower-upper)*fLower/(fUpper-fLower)
+		fC := f(c)
+		if fC*fUpper <= 0 {
+			lower, fLower = upper, fUpper
+		} else {
+			fLower /= 2
+		}
+		upper, fUpper = c, fC
+	}
+
+	return math.Exp(lower / 2)
+}
@@ -0,0 +1,87 @@
+package imagehash
+
+import (
+	"image"
+	"math/bits"
+
+	"github.com/boobsrate/core/pkg/imageproc"
+)
+
+const (
+	hashWidth      = 9
+	hashHeight     = 8
+	samplesPerCell = 32
+)
+
+// DHashBytes decodes the image and returns its difference hash. It accepts the same formats
+// and sizes as imageproc.Decode.
+func DHashBytes(b []byte) (uint64, error) {
+	img, _, err := imageproc.Decode(b)
+	if err != nil {
+		return 0, err
+	}
+	return DHash(img), nil
+}
+
+// DHash returns the difference hash of the image: the image is shrunk to 9x8 grayscale
+// pixels and every bit tells whether a pixel is brighter than its right neighbour.
+// Resized, recompressed or slightly edited copies of an image get hashes a few bits apart.
+func DHash(img image.Image) uint64 {
+	pixels := shrink(img)
+
+	var hash uint64
+	for y := 0; y < hashHeight; y++ {
+		for x := 0; x < hashWidth-1; x++ {
+			hash <<= 1
+			if pixels[y][x] > pixels[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// Distance returns the number of differing bits of two hashes.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// shrink averages the luminance of the image over a hashWidth x hashHeight grid.
+func shrink(img image.Image) [hashHeight][hashWidth]float64 {
+	var sums [hashHeight][hashWidth]float64
+	var counts [hashHeight][hashWidth]float64
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return sums
+	}
+
+	// Large images are sampled, samplesPerCell pixels per cell side are plenty for an average.
+	stepX, stepY := 1, 1
+	if width > hashWidth*samplesPerCell {
+		stepX = width / (hashWidth * samplesPerCell)
+	}
+	if height > hashHeight*samplesPerCell {
+		stepY = height / (hashHeight * samplesPerCell)
+	}
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += stepY {
+		cellY := (y - bounds.Min.Y) * hashHeight / height
+		for x := bounds.Min.X; x < bounds.Max.X; x += stepX {
+			cellX := (x - bounds.Min.X) * hashWidth / width
+			r, g, b, _ := img.At(x, y).RGBA()
+			sums[cellY][cellX] += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+			counts[cellY][cellX]++
+		}
+	}
+
+	for y := range sums {
+		for x := range sums[y] {
+			if counts[y][x] > 0 {
+				sums[y][x] /= counts[y][x]
+			}
+		}
+	}
+	return sums
+}
@@ -0,0 +1,143 @@
+package imageproc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"net/http"
+	"strings"
+
+	// Decoders of the formats we accept.
+	_ "image/gif"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// MaxPixels is the largest width*height Decode accepts. Decoding allocates memory for every pixel,
+// so a small file declaring huge dimensions would otherwise take gigabytes.
+const MaxPixels = 50_000_000
+
+// ErrTooLarge is returned for images with more than MaxPixels pixels.
+var ErrTooLarge = errors.New("image is too large")
+
+// Decode decodes a JPEG, PNG, GIF or WebP image of at most MaxPixels pixels as stored, ignoring
+// EXIF orientation, and returns its format. The dimensions are checked before any pixel is decoded.
+func Decode(data []byte) (image.Image, string, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("decode image config: %w", err)
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > MaxPixels {
+		return nil, "", fmt.Errorf("%w: %dx%d", ErrTooLarge, cfg.Width, cfg.Height)
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("decode image: %w", err)
+	}
+	return img, format, nil
+}
+
+// DecodeResized decodes the image and scales it down to the width it has once upright. JPEGs are
+// turned upright according to their EXIF orientation after scaling, so only the small image is rotated.
+func DecodeResized(data []byte, width int) (image.Image, error) {
+	img, format, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	orientation := 1
+	if format == "jpeg" {
+		orientation = jpegOrientation(data)
+	}
+	if orientation >= 5 {
+		// The sides are swapped once the image is upright, its height is the width shown.
+		img = resizeHeight(img, width)
+	} else {
+		img = Resize(img, width)
+	}
+	return orient(img, orientation), nil
+}
+
+// Resize scales the image down to the width keeping its aspect ratio. Smaller images are returned as is.
+func Resize(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	if width <= 0 || bounds.Dx() <= width {
+		return img
+	}
+
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+	return scale(img, width, height)
+}
+
+// resizeHeight scales the image down to the height keeping its aspect ratio. Smaller images are returned as is.
+func resizeHeight(img image.Image, height int) image.Image {
+	bounds := img.Bounds()
+	if height <= 0 || bounds.Dy() <= height {
+		return img
+	}
+
+	width := bounds.Dx() * height / bounds.Dy()
+	if width < 1 {
+		width = 1
+	}
+	return scale(img, width, height)
+}
+
+func scale(img image.Image, width, height int) image.Image {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Src, nil)
+	return dst
+}
+
+func EncodeJPEG(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, fmt.Errorf("encode jpeg: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Info describes an encoded image.
+type Info struct {
+	ContentType string
+	// Format is the subtype of the content type, e.g. jpeg or webp.
+	Format string
+	Width  int
+	Height int
+}
+
+// Validate checks that data is a decodable image and describes it.
+// It guards the storage against error pages and truncated responses of image backends.
+func Validate(data []byte) (Info, error) {
+	if len(data) == 0 {
+		return Info{}, errors.New("image is empty")
+	}
+
+	contentType := http.DetectContentType(data)
+	format, ok := strings.CutPrefix(contentType, "image/")
+	if !ok {
+		return Info{}, fmt.Errorf("not an image: %s", contentType)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Info{}, fmt.Errorf("decode image config: %w", err)
+	}
+	if cfg.Width == 0 || cfg.Height == 0 {
+		return Info{}, errors.New("image has no pixels")
+	}
+	// Report the size the image is displayed at.
+	width, height := cfg.Width, cfg.Height
+	if format == "jpeg" && jpegOrientation(data) >= 5 {
+		width, height = height, width
+	}
+	return Info{ContentType: contentType, Format: format, Width: width, Height: height}, nil
+}
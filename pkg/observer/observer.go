@@ -75,12 +75,7 @@ func NewObserver() *Observer {
 	}
 	observer.AddUpper(handleSignals)
 
-	return &Observer{
-		openTimeout:  defaultOpenTimeout,
-		closeTimeout: defaultCloseTimeout,
-		ctx:          ctx,
-		cancelFunc:   cancel,
-	}
+	return observer
 }
 
 func (o *Observer) AddContextOpener(contextOpener ContextOpener) {
@@ -22,6 +22,7 @@ func NewGracefulServer(server *http.Server, log *zap.Logger) *GracefulServer {
 	return &GracefulServer{
 		log:    log,
 		server: server,
+		dead:   make(chan struct{}),
 	}
 }
 
@@ -51,6 +52,7 @@ func (s *GracefulServer) Dead() <-chan struct{} {
 func ApplyCors(router *mux.Router) http.Handler {
 	c := cors.New(cors.Options{
 		AllowCredentials: true,
+		ExposedHeaders:   []string{"X-Duel-Token"},
 		Debug:            false,
 	})
 